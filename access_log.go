@@ -0,0 +1,151 @@
+package logging
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AccessLogFormat is the line format written by [AccessLog]
+// and [WithAccessLog].
+type AccessLogFormat int
+
+const (
+	// CommonLogFormat is the NCSA Common Log Format:
+	//
+	//	host ident authuser [date] "request" status bytes
+	CommonLogFormat AccessLogFormat = iota
+	// CombinedLogFormat is the NCSA Combined Log Format,
+	// which adds the referer and user agent to the [CommonLogFormat]:
+	//
+	//	host ident authuser [date] "request" status bytes "referer" "user-agent"
+	CombinedLogFormat
+)
+
+const accessLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLog writes a line in the passed format to out
+// for every request, after the next handler returns.
+// It does not print any slog records. To write access log lines
+// alongside the request logs of [Middleware], use [WithAccessLog].
+func AccessLog(out io.Writer, format AccessLogFormat) func(http.Handler) http.Handler {
+	al := newAccessLogger(out, format)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			lw := newLoggedWriter(w)
+			next.ServeHTTP(lw, r)
+			al.log(r, start, lw)
+		})
+	}
+}
+
+type accessLogger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	format AccessLogFormat
+}
+
+func newAccessLogger(out io.Writer, format AccessLogFormat) *accessLogger {
+	return &accessLogger{
+		out:    out,
+		format: format,
+	}
+}
+
+// AccessLoggedWriter is a [LoggedWriter] which provides
+// the status and bytes of access log lines.
+// For other writers passed to [WithLoggedWriter],
+// status and bytes are written as "-".
+type AccessLoggedWriter interface {
+	LoggedWriter
+
+	// Status returns the status code written,
+	// or 0 if none was written.
+	Status() int
+	// Written returns the amount of body bytes written.
+	Written() int
+}
+
+// log writes a single line.
+func (a *accessLogger) log(r *http.Request, start time.Time, lw LoggedWriter) {
+	status, written := "-", "-"
+	if w, ok := lw.(AccessLoggedWriter); ok {
+		statusCode := w.Status()
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+		status = strconv.Itoa(statusCode)
+		if n := w.Written(); n > 0 {
+			written = strconv.Itoa(n)
+		}
+	}
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+
+	b := make([]byte, 0, 256)
+	b = append(b, accessLogField(remoteHost(r))...)
+	b = append(b, " - "...)
+	b = append(b, accessLogField(remoteUser(r))...)
+	b = append(b, " ["...)
+	b = start.AppendFormat(b, accessLogTimeFormat)
+	b = append(b, "] "...)
+	b = strconv.AppendQuote(b, r.Method+" "+uri+" "+r.Proto)
+	b = append(b, ' ')
+	b = append(b, status...)
+	b = append(b, ' ')
+	b = append(b, written...)
+	if a.format == CombinedLogFormat {
+		b = append(b, ' ')
+		b = appendQuotedField(b, r.Referer())
+		b = append(b, ' ')
+		b = appendQuotedField(b, r.UserAgent())
+	}
+	b = append(b, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, _ = a.out.Write(b)
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func remoteUser(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok {
+		return user
+	}
+	return ""
+}
+
+// accessLogField returns "-" for empty values
+// and quotes values containing spaces or control characters.
+func accessLogField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	for _, c := range s {
+		if c <= ' ' || c == '"' || c >= 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// appendQuotedField appends the quoted value, or "-" if empty.
+func appendQuotedField(b []byte, s string) []byte {
+	if s == "" {
+		return append(b, `"-"`...)
+	}
+	return strconv.AppendQuote(b, s)
+}
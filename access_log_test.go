@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_accessLogger_log(t *testing.T) {
+	start := time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60))
+	tests := []struct {
+		name    string
+		format  AccessLogFormat
+		request func() *http.Request
+		handler http.HandlerFunc
+		want    string
+	}{
+		{
+			name:   "common",
+			format: CommonLogFormat,
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/apache_pb.gif?x=1", nil)
+				r.RemoteAddr = "127.0.0.1:1234"
+				r.SetBasicAuth("frank", "secret")
+				return r
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, strings.Repeat("a", 2326))
+			},
+			want: `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif?x=1 HTTP/1.1" 200 2326` + "\n",
+		},
+		{
+			name:   "combined",
+			format: CombinedLogFormat,
+			request: func() *http.Request {
+				r := httptest.NewRequest("POST", "/login", nil)
+				r.RemoteAddr = "[::1]:1234"
+				r.Header.Set("Referer", "http://www.example.com/start.html")
+				r.Header.Set("User-Agent", `Mozilla/4.08 [en] (Win98; I ;"Nav")`)
+				return r
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			want: `::1 - - [10/Oct/2000:13:55:36 -0700] "POST /login HTTP/1.1" 204 - "http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;\"Nav\")"` + "\n",
+		},
+		{
+			name:   "escaped user",
+			format: CommonLogFormat,
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/", nil)
+				r.RemoteAddr = "127.0.0.1:1234"
+				r.SetBasicAuth("evil user\n", "secret")
+				return r
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			want:    `127.0.0.1 - "evil user\n" [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.1" 200 -` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(strings.Builder)
+			al := newAccessLogger(out, tt.format)
+			r := tt.request()
+			lw := newLoggedWriter(httptest.NewRecorder())
+			tt.handler(lw, r)
+			al.log(r, start, lw)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	out := new(strings.Builder)
+	handler := AccessLog(out, CommonLogFormat)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+	assert.Regexp(t, `^192\.0\.2\.1 - - \[.+\] "GET /missing HTTP/1\.1" 404 19\n$`, out.String())
+}
+
+func TestWithAccessLog(t *testing.T) {
+	out := new(strings.Builder)
+	logOut, logger := newTestLogger()
+	handler := Middleware(
+		WithLogger(logger),
+		WithAccessLog(out, CombinedLogFormat),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Hello, World!")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/path", nil))
+	assert.Regexp(t, `^192\.0\.2\.1 - - \[.+\] "GET /path HTTP/1\.1" 200 13 "-" "-"\n$`, out.String())
+	assert.Contains(t, logOut.String(), "request served")
+}
+
+// customLoggedWriter is a [LoggedWriter] of another type,
+// which provides the status and bytes of the default one.
+type customLoggedWriter struct {
+	AccessLoggedWriter
+}
+
+func TestWithAccessLog_loggedWriter(t *testing.T) {
+	out := new(strings.Builder)
+	_, logger := newTestLogger()
+	handler := Middleware(
+		WithLogger(logger),
+		WithLoggedWriter(func(w http.ResponseWriter) LoggedWriter {
+			return customLoggedWriter{newLoggedWriter(w).(AccessLoggedWriter)}
+		}),
+		WithAccessLog(out, CommonLogFormat),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "created")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/path", nil))
+	assert.Regexp(t, `^192\.0\.2\.1 - - \[.+\] "POST /path HTTP/1\.1" 201 7\n$`, out.String())
+}
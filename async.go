@@ -0,0 +1,435 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DropPolicy decides what happens to a record
+// when the queue of an async output is full.
+type DropPolicy string
+
+const (
+	// DropNewest drops the record being logged.
+	DropNewest DropPolicy = "newest"
+	// DropOldest drops the oldest queued record
+	// to make room for the record being logged.
+	DropOldest DropPolicy = "oldest"
+	// DropBlock blocks the caller until there is room in the queue
+	// or the BlockTimeout passed, then drops the record being logged.
+	DropBlock DropPolicy = "block"
+)
+
+// AsyncOptions configure [AsyncHandler] and [AsyncWriter].
+type AsyncOptions struct {
+	// QueueSize is the maximum amount of queued records,
+	// defaults to 1000.
+	QueueSize int `json:"queueSize" yaml:"queueSize"`
+	// DropPolicy applies when the queue is full,
+	// defaults to [DropNewest], which is also used
+	// for unknown policies.
+	DropPolicy DropPolicy `json:"dropPolicy" yaml:"dropPolicy"`
+	// BlockTimeout is the maximum time [DropBlock] and
+	// KeepErrors wait, defaults to 100ms. It is given in
+	// nanoseconds in JSON and like "100ms" in YAML.
+	BlockTimeout time.Duration `json:"blockTimeout" yaml:"blockTimeout"`
+	// KeepErrors never drops records at ERROR level or above.
+	// When the queue is full, the oldest record below ERROR
+	// is dropped instead. If there is none, the caller is
+	// blocked until there is room or the BlockTimeout passed,
+	// then the record is dropped.
+	KeepErrors bool `json:"keepErrors" yaml:"keepErrors"`
+	// ErrorHandler is called with the errors returned
+	// by the wrapped handler or writer.
+	// The default prints the error to stderr.
+	ErrorHandler func(error) `json:"-" yaml:"-"`
+}
+
+func (o AsyncOptions) withDefaults() AsyncOptions {
+	if o.QueueSize <= 0 {
+		o.QueueSize = 1000
+	}
+	if o.validate() != nil || o.DropPolicy == "" {
+		o.DropPolicy = DropNewest
+	}
+	if o.BlockTimeout <= 0 {
+		o.BlockTimeout = 100 * time.Millisecond
+	}
+	if o.ErrorHandler == nil {
+		o.ErrorHandler = printError
+	}
+	return o
+}
+
+func (o AsyncOptions) validate() error {
+	switch o.DropPolicy {
+	case "", DropNewest, DropOldest, DropBlock:
+		return nil
+	default:
+		return fmt.Errorf("%s drop policy not supported", o.DropPolicy)
+	}
+}
+
+// AsyncStats are the counters of an async output.
+type AsyncStats struct {
+	// Queued is the amount of records waiting in the queue.
+	Queued int
+	// Handled is the amount of records passed
+	// to the wrapped handler or writer.
+	Handled uint64
+	// Dropped is the amount of records dropped
+	// because the queue was full.
+	Dropped uint64
+}
+
+// AsyncHandler passes records through a bounded queue to a
+// wrapped handler, which is called from a single background goroutine.
+// This keeps slow outputs off the request path.
+// When the queue is full, the [DropPolicy] applies.
+//
+// Flush and Close wait for the queued records to be handled
+// and then call Flush and Close of the wrapped handler,
+// if it implements them, like a [Sink] does.
+type AsyncHandler struct {
+	handler slog.Handler
+	queue   *asyncQueue[asyncRecord]
+	root    slog.Handler
+}
+
+type asyncRecord struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+}
+
+// NewAsyncHandler wraps handler and starts the background goroutine.
+func NewAsyncHandler(handler slog.Handler, opts AsyncOptions) *AsyncHandler {
+	opts = opts.withDefaults()
+	return &AsyncHandler{
+		handler: handler,
+		root:    handler,
+		queue: newAsyncQueue(opts, func(r asyncRecord) {
+			if err := r.handler.Handle(r.ctx, r.record); err != nil {
+				opts.ErrorHandler(err)
+			}
+		}),
+	}
+}
+
+func (h *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{handler: h.handler.WithAttrs(attrs), queue: h.queue, root: h.root}
+}
+
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	return &AsyncHandler{handler: h.handler.WithGroup(name), queue: h.queue, root: h.root}
+}
+
+// Handle queues a clone of the record. The context passed to the
+// wrapped handler keeps the values of ctx, but is never canceled.
+// It only returns an error after Close.
+func (h *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.queue.add(r.Level, asyncRecord{
+		ctx:     context.WithoutCancel(ctx),
+		handler: h.handler,
+		record:  r.Clone(),
+	})
+}
+
+// Stats returns the counters of the queue.
+func (h *AsyncHandler) Stats() AsyncStats {
+	return h.queue.stats()
+}
+
+// Flush waits until all queued records are handled
+// and flushes the wrapped handler.
+func (h *AsyncHandler) Flush(ctx context.Context) error {
+	if err := h.queue.flush(ctx); err != nil {
+		return err
+	}
+	return flushHandler(ctx, h.root)
+}
+
+// Close stops accepting records, waits until the queued records
+// are handled and closes the wrapped handler.
+func (h *AsyncHandler) Close(ctx context.Context) error {
+	if err := h.queue.close(ctx); err != nil {
+		return err
+	}
+	return closeHandler(ctx, h.root)
+}
+
+// AsyncWriter passes writes through a bounded queue to a wrapped
+// writer, which is called from a single background goroutine.
+// When the queue is full, the [DropPolicy] applies.
+// Use [AsyncWriter.Hook] to keep the level of logrus entries.
+type AsyncWriter struct {
+	queue *asyncQueue[[]byte]
+}
+
+// NewAsyncWriter wraps w and starts the background goroutine.
+// Close does not close w.
+func NewAsyncWriter(w io.Writer, opts AsyncOptions) *AsyncWriter {
+	opts = opts.withDefaults()
+	return &AsyncWriter{
+		queue: newAsyncQueue(opts, func(p []byte) {
+			if _, err := w.Write(p); err != nil {
+				opts.ErrorHandler(err)
+			}
+		}),
+	}
+}
+
+// Write queues a copy of p as a record at INFO level.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(slog.LevelInfo, p)
+}
+
+// WriteLevel queues a copy of p as a record at level.
+func (w *AsyncWriter) WriteLevel(level slog.Level, p []byte) (int, error) {
+	if err := w.queue.add(level, slices.Clone(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Hook returns a [logrus.Hook] which writes the entries, formatted
+// by formatter, to w at their level. The Out of the logger
+// should be set to [io.Discard], to not write the entries twice.
+func (w *AsyncWriter) Hook(formatter logrus.Formatter) logrus.Hook {
+	return &asyncWriterHook{writer: w, formatter: formatter}
+}
+
+// Stats returns the counters of the queue.
+func (w *AsyncWriter) Stats() AsyncStats {
+	return w.queue.stats()
+}
+
+// Flush waits until all queued records are written.
+func (w *AsyncWriter) Flush(ctx context.Context) error {
+	return w.queue.flush(ctx)
+}
+
+// Close stops accepting records and waits until
+// the queued records are written.
+func (w *AsyncWriter) Close(ctx context.Context) error {
+	return w.queue.close(ctx)
+}
+
+type asyncWriterHook struct {
+	writer    *AsyncWriter
+	formatter logrus.Formatter
+}
+
+func (h *asyncWriterHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *asyncWriterHook) Fire(entry *logrus.Entry) error {
+	b, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.writer.WriteLevel(slogLevel(entry.Level), b)
+	return err
+}
+
+// asyncQueue is a bounded queue of items,
+// which are processed by a single background goroutine.
+type asyncQueue[T any] struct {
+	opts    AsyncOptions
+	process func(T)
+
+	mu      sync.Mutex
+	items   []asyncItem[T]
+	busy    bool
+	closed  bool
+	handled uint64
+	dropped uint64
+	// changed is closed and replaced when an item
+	// is dequeued or processed.
+	changed chan struct{}
+
+	queued  chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+type asyncItem[T any] struct {
+	level slog.Level
+	item  T
+}
+
+func newAsyncQueue[T any](opts AsyncOptions, process func(T)) *asyncQueue[T] {
+	q := &asyncQueue[T]{
+		opts:    opts,
+		process: process,
+		changed: make(chan struct{}),
+		queued:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *asyncQueue[T]) run() {
+	defer close(q.stopped)
+	for {
+		q.mu.Lock()
+		if len(q.items) == 0 {
+			closed := q.closed
+			q.mu.Unlock()
+			if closed {
+				return
+			}
+			select {
+			case <-q.queued:
+			case <-q.done:
+			}
+			continue
+		}
+		item := q.items[0]
+		q.items = q.items[1:]
+		q.busy = true
+		q.broadcast()
+		q.mu.Unlock()
+
+		q.process(item.item)
+
+		q.mu.Lock()
+		q.busy = false
+		q.handled++
+		q.broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// broadcast wakes up all callers waiting for a change.
+// It must be called with mu held.
+func (q *asyncQueue[T]) broadcast() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+func (q *asyncQueue[T]) add(level slog.Level, item T) error {
+	var timeout <-chan time.Time
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if q.closed {
+			return ErrClosed
+		}
+		if len(q.items) < q.opts.QueueSize {
+			q.push(level, item)
+			return nil
+		}
+		if q.opts.KeepErrors && level >= slog.LevelError {
+			i := slices.IndexFunc(q.items, func(i asyncItem[T]) bool {
+				return i.level < slog.LevelError
+			})
+			if i >= 0 {
+				q.items = slices.Delete(q.items, i, i+1)
+				q.dropped++
+				q.push(level, item)
+				return nil
+			}
+		} else {
+			switch q.opts.DropPolicy {
+			case DropOldest:
+				q.items = q.items[1:]
+				q.dropped++
+				q.push(level, item)
+				return nil
+			case DropBlock:
+			default:
+				q.dropped++
+				return nil
+			}
+		}
+		if timeout == nil {
+			timer := time.NewTimer(q.opts.BlockTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-changed:
+			q.mu.Lock()
+		case <-timeout:
+			q.mu.Lock()
+			q.dropped++
+			return nil
+		}
+	}
+}
+
+// push appends the item and wakes up the background goroutine.
+// It must be called with mu held.
+func (q *asyncQueue[T]) push(level slog.Level, item T) {
+	q.items = append(q.items, asyncItem[T]{level: level, item: item})
+	select {
+	case q.queued <- struct{}{}:
+	default:
+	}
+}
+
+func (q *asyncQueue[T]) stats() AsyncStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return AsyncStats{
+		Queued:  len(q.items),
+		Handled: q.handled,
+		Dropped: q.dropped,
+	}
+}
+
+// flush waits until the queue is empty
+// and the last item was processed.
+func (q *asyncQueue[T]) flush(ctx context.Context) error {
+	for {
+		q.mu.Lock()
+		if len(q.items) == 0 && !q.busy {
+			q.mu.Unlock()
+			return nil
+		}
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// close stops accepting items and waits until
+// the background goroutine processed the queued items.
+func (q *asyncQueue[T]) close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrClosed
+	}
+	q.closed = true
+	q.broadcast()
+	q.mu.Unlock()
+	close(q.done)
+	select {
+	case <-q.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
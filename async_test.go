@@ -0,0 +1,275 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockedQueue returns a queue of size 2, which blocks processing
+// until release is closed. Item 0 is already being processed.
+func blockedQueue(t *testing.T, opts AsyncOptions) (q *asyncQueue[int], processed func() []int, release chan struct{}) {
+	var (
+		mu   sync.Mutex
+		done []int
+	)
+	release = make(chan struct{})
+	opts.QueueSize = 2
+	q = newAsyncQueue(opts.withDefaults(), func(i int) {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		done = append(done, i)
+	})
+	require.NoError(t, q.add(slog.LevelInfo, 0))
+	require.Eventually(t, func() bool {
+		return q.stats().Queued == 0
+	}, time.Second, time.Millisecond)
+	return q, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return done
+	}, release
+}
+
+func Test_asyncQueue_dropPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		opts AsyncOptions
+		want []int
+	}{
+		{
+			name: "newest",
+			opts: AsyncOptions{DropPolicy: DropNewest},
+			want: []int{0, 1, 2},
+		},
+		{
+			name: "oldest",
+			opts: AsyncOptions{DropPolicy: DropOldest},
+			want: []int{0, 3, 4},
+		},
+		{
+			name: "block",
+			opts: AsyncOptions{DropPolicy: DropBlock, BlockTimeout: time.Millisecond},
+			want: []int{0, 1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, processed, release := blockedQueue(t, tt.opts)
+			for i := 1; i <= 4; i++ {
+				require.NoError(t, q.add(slog.LevelInfo, i))
+			}
+			assert.Equal(t, AsyncStats{Queued: 2, Dropped: 2}, q.stats())
+			close(release)
+			require.NoError(t, q.close(context.Background()))
+			assert.Equal(t, tt.want, processed())
+			assert.Equal(t, AsyncStats{Handled: 3, Dropped: 2}, q.stats())
+			assert.ErrorIs(t, q.add(slog.LevelInfo, 5), ErrClosed)
+			assert.ErrorIs(t, q.close(context.Background()), ErrClosed)
+		})
+	}
+}
+
+func Test_asyncQueue_block(t *testing.T) {
+	q, processed, release := blockedQueue(t, AsyncOptions{DropPolicy: DropBlock, BlockTimeout: time.Minute})
+	require.NoError(t, q.add(slog.LevelInfo, 1))
+	require.NoError(t, q.add(slog.LevelInfo, 2))
+	time.AfterFunc(10*time.Millisecond, func() { close(release) })
+	require.NoError(t, q.add(slog.LevelInfo, 3))
+	require.NoError(t, q.flush(context.Background()))
+	assert.Equal(t, []int{0, 1, 2, 3}, processed())
+	assert.Equal(t, AsyncStats{Handled: 4}, q.stats())
+	require.NoError(t, q.close(context.Background()))
+}
+
+func Test_asyncQueue_keepErrors(t *testing.T) {
+	q, processed, release := blockedQueue(t, AsyncOptions{KeepErrors: true, BlockTimeout: time.Minute})
+	require.NoError(t, q.add(slog.LevelInfo, 1))
+	require.NoError(t, q.add(slog.LevelError, 2))
+	require.NoError(t, q.add(slog.LevelError, 3))
+	require.NoError(t, q.add(slog.LevelWarn, 4))
+
+	added := make(chan struct{})
+	go func() {
+		defer close(added)
+		assert.NoError(t, q.add(slog.LevelError, 5))
+	}()
+	select {
+	case <-added:
+		t.Fatal("error record did not block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	<-added
+	require.NoError(t, q.close(context.Background()))
+	assert.Equal(t, []int{0, 2, 3, 5}, processed())
+	assert.Equal(t, AsyncStats{Handled: 4, Dropped: 2}, q.stats())
+}
+
+func Test_asyncQueue_keepErrorsTimeout(t *testing.T) {
+	q, processed, release := blockedQueue(t, AsyncOptions{KeepErrors: true, BlockTimeout: time.Millisecond})
+	require.NoError(t, q.add(slog.LevelError, 1))
+	require.NoError(t, q.add(slog.LevelError, 2))
+	require.NoError(t, q.add(slog.LevelError, 3))
+	assert.Equal(t, AsyncStats{Queued: 2, Dropped: 1}, q.stats())
+	close(release)
+	require.NoError(t, q.close(context.Background()))
+	assert.Equal(t, []int{0, 1, 2}, processed())
+}
+
+func Test_asyncQueue_unknownPolicy(t *testing.T) {
+	q, processed, release := blockedQueue(t, AsyncOptions{DropPolicy: "random"})
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, q.add(slog.LevelInfo, i))
+	}
+	close(release)
+	require.NoError(t, q.close(context.Background()))
+	assert.Equal(t, []int{0, 1, 2}, processed())
+}
+
+func Test_asyncQueue_flushContext(t *testing.T) {
+	q, _, release := blockedQueue(t, AsyncOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.flush(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, q.close(ctx), context.DeadlineExceeded)
+	close(release)
+}
+
+func TestAsyncHandler(t *testing.T) {
+	var buf bytes.Buffer
+	var errs []error
+	h := NewAsyncHandler(&errorHandler{slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: removeTestTime,
+	})}, AsyncOptions{
+		ErrorHandler: func(err error) { errs = append(errs, err) },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	logger := slog.New(h).With("id", 1).WithGroup("g")
+	attrs := []any{"a", 1}
+	logger.InfoContext(ctx, "hello", attrs...)
+	attrs[1] = 2
+	logger.Error("fail")
+	assert.True(t, h.Enabled(ctx, slog.LevelInfo))
+	assert.False(t, h.Enabled(ctx, slog.LevelDebug))
+
+	require.NoError(t, h.Flush(context.Background()))
+	assert.Equal(t, `{"level":"INFO","msg":"hello","id":1,"g":{"a":1}}
+{"level":"ERROR","msg":"fail","id":1}
+`, buf.String())
+	assert.Equal(t, []error{errFailed}, errs)
+	assert.Equal(t, AsyncStats{Handled: 2}, h.Stats())
+	require.NoError(t, h.Close(context.Background()))
+	assert.ErrorIs(t, logger.Handler().Handle(ctx, slog.Record{}), ErrClosed)
+}
+
+func TestAsyncHandler_sink(t *testing.T) {
+	sender := new(testSender)
+	sink := &testSink{b: newBatcher(BatchOptions{FlushInterval: time.Hour}, sender.send, nil)}
+	h := NewAsyncHandler(sink, AsyncOptions{})
+	l := logrus.New()
+	l.Out = io.Discard
+	l.AddHook(NewSlogHook(h))
+	l.Info("one")
+	l.Info("two")
+
+	require.NoError(t, h.Flush(context.Background()))
+	assert.Equal(t, [][]int{{1, 2}}, sender.get())
+	require.NoError(t, h.Close(context.Background()))
+	assert.True(t, sink.closed)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewAsyncWriter(&buf, AsyncOptions{})
+	l := logrus.New()
+	l.Out = io.Discard
+	l.Formatter = &LogfmtFormatter{DisableTimestamp: true}
+	l.AddHook(w.Hook(l.Formatter))
+	l.WithField("user", "gigi").Warn("from logrus")
+	_, err := w.Write([]byte("written\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, "level=warning msg=\"from logrus\" user=gigi\nwritten\n", buf.String())
+	assert.Equal(t, AsyncStats{Handled: 2}, w.Stats())
+	_, err = w.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+var errFailed = errors.New("failed")
+
+// errorHandler fails records at ERROR level after handling them.
+type errorHandler struct {
+	slog.Handler
+}
+
+func (h *errorHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &errorHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *errorHandler) WithGroup(name string) slog.Handler {
+	return &errorHandler{h.Handler.WithGroup(name)}
+}
+
+func (h *errorHandler) Handle(ctx context.Context, r slog.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := h.Handler.Handle(ctx, r); err != nil {
+		return err
+	}
+	if r.Level >= slog.LevelError {
+		return errFailed
+	}
+	return nil
+}
+
+// testSink counts the records in batches.
+type testSink struct {
+	b      *batcher[int]
+	mu     sync.Mutex
+	n      int
+	closed bool
+}
+
+func (s *testSink) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (s *testSink) WithAttrs([]slog.Attr) slog.Handler {
+	return s
+}
+
+func (s *testSink) WithGroup(string) slog.Handler {
+	return s
+}
+
+func (s *testSink) Handle(context.Context, slog.Record) error {
+	s.mu.Lock()
+	s.n++
+	n := s.n
+	s.mu.Unlock()
+	return s.b.add(n)
+}
+
+func (s *testSink) Flush(ctx context.Context) error {
+	return s.b.Flush(ctx)
+}
+
+func (s *testSink) Close(ctx context.Context) error {
+	s.closed = true
+	return s.b.Close(ctx)
+}
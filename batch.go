@@ -0,0 +1,318 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// BatchOptions configure how sinks which send records
+// over the network batch them.
+type BatchOptions struct {
+	// BatchSize is the maximum amount of records sent at once,
+	// defaults to 100.
+	BatchSize int `json:"batchSize" yaml:"batchSize"`
+	// FlushInterval is the maximum time records are buffered
+	// before they are sent, defaults to 1 second. JSON takes
+	// nanoseconds, YAML also a duration like "5s".
+	FlushInterval time.Duration `json:"flushInterval" yaml:"flushInterval"`
+	// MaxBuffered is the maximum amount of records buffered while
+	// sending is slow or failing, defaults to 10 times the BatchSize.
+	// When exceeded, the oldest records are dropped.
+	MaxBuffered int `json:"maxBuffered" yaml:"maxBuffered"`
+	// ErrorHandler is called when a batch could not be sent
+	// or records were dropped.
+	// The default prints the error to stderr.
+	ErrorHandler func(error) `json:"-" yaml:"-"`
+	// Spool persists the records which could not be sent,
+	// if its Dir is set. See [SpoolOptions].
+	Spool SpoolOptions `json:"spool"`
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = time.Second
+	}
+	if o.MaxBuffered < o.BatchSize {
+		o.MaxBuffered = 10 * o.BatchSize
+	}
+	if o.ErrorHandler == nil {
+		o.ErrorHandler = printError
+	}
+	return o
+}
+
+func printError(err error) {
+	fmt.Fprintln(os.Stderr, "logging:", err)
+}
+
+// ErrClosed is returned by sinks which were already closed.
+var ErrClosed = errors.New("logging: sink closed")
+
+// batcher buffers items and passes them in batches to send,
+// when BatchSize is reached or FlushInterval passed.
+// Batches are sent from a single background goroutine,
+// so send is never called concurrently.
+//
+// The background flush uses a context, which is canceled by Close,
+// if the context passed to Close is done first.
+//
+// With a spool, the items of batches failing with a temporary
+// error are spooled. While the spool is not empty, new batches
+// are spooled too, to keep the order. Each flush first tries
+// to send the spooled items again.
+type batcher[T any] struct {
+	opts  BatchOptions
+	send  func(ctx context.Context, batch []T) error
+	codec *spoolCodec[T]
+	spool *spool
+
+	mu      sync.Mutex
+	items   []T
+	dropped int
+	closed  bool
+
+	// sending is locked while a flush sends batches.
+	// It is a channel, so waiting for it can be canceled.
+	sending chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	full    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// newBatcher creates a batcher and starts its background goroutine.
+// Items are only spooled if codec is not nil.
+func newBatcher[T any](opts BatchOptions, send func(ctx context.Context, batch []T) error, codec *spoolCodec[T]) *batcher[T] {
+	b := &batcher[T]{
+		opts:    opts.withDefaults(),
+		send:    send,
+		codec:   codec,
+		sending: make(chan struct{}, 1),
+		full:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	if codec != nil && b.opts.Spool.Dir != "" {
+		var err error
+		if b.spool, err = openSpool(b.opts.Spool); err != nil {
+			b.opts.ErrorHandler(fmt.Errorf("spool disabled: %w", err))
+		}
+	}
+	go b.run()
+	return b
+}
+
+func (b *batcher[T]) run() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.full:
+		case <-b.done:
+			return
+		}
+		if err := b.flush(b.ctx); err != nil {
+			b.opts.ErrorHandler(err)
+		}
+	}
+}
+
+func (b *batcher[T]) add(item T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	if len(b.items) >= b.opts.MaxBuffered {
+		b.items = b.items[1:]
+		b.dropped++
+	}
+	b.items = append(b.items, item)
+	if len(b.items) >= b.opts.BatchSize {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// flush sends all buffered items, in batches of at most BatchSize.
+// Items of a failed batch are dropped, unless they are spooled.
+func (b *batcher[T]) flush(ctx context.Context) error {
+	if err := b.lock(ctx); err != nil {
+		return err
+	}
+	defer b.unlock()
+	var errs []error
+	if b.spool != nil {
+		errs = append(errs, b.replay(ctx))
+	}
+	for {
+		b.mu.Lock()
+		if b.dropped > 0 {
+			errs = append(errs, fmt.Errorf("dropped %d records, buffer full", b.dropped))
+			b.dropped = 0
+		}
+		n := min(len(b.items), b.opts.BatchSize)
+		batch := b.items[:n:n]
+		b.items = b.items[n:]
+		b.mu.Unlock()
+		if n == 0 {
+			return errors.Join(errs...)
+		}
+		if b.spool != nil && (!b.spool.empty() || ctx.Err() != nil) {
+			errs = append(errs, b.spoolItems(batch))
+			continue
+		}
+		if err := b.send(ctx, batch); err != nil {
+			errs = append(errs, b.failed(batch, err))
+		}
+		if b.spool == nil && ctx.Err() != nil {
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+	}
+}
+
+// lock waits until no other flush is sending, or ctx is done.
+func (b *batcher[T]) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case b.sending <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *batcher[T]) unlock() {
+	<-b.sending
+}
+
+// failed spools the items of the failed batch, which may be sent
+// again. The error is only reported when spooling starts, as
+// following batches are spooled until the destination is available.
+func (b *batcher[T]) failed(batch []T, err error) error {
+	if b.spool == nil {
+		return err
+	}
+	items := undelivered(batch, err)
+	if len(items) == 0 {
+		return err
+	}
+	started := b.spool.empty()
+	if spoolErr := b.spoolItems(items); spoolErr != nil {
+		return errors.Join(err, spoolErr)
+	}
+	if started {
+		return fmt.Errorf("spooling records until the destination is available: %w", err)
+	}
+	return nil
+}
+
+func (b *batcher[T]) spoolItems(items []T) error {
+	var errs []error
+	records := make([][]byte, 0, len(items))
+	for _, item := range items {
+		record, err := b.codec.encode(item)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("dropped record: %w", err))
+			continue
+		}
+		records = append(records, record)
+	}
+	removed, err := b.spool.write(records)
+	if removed > 0 {
+		errs = append(errs, fmt.Errorf("spool full, removed %d bytes of the oldest records", removed))
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("dropped %d records, spooling failed: %w", len(records), err))
+	}
+	return errors.Join(errs...)
+}
+
+// replay sends the spooled items in batches, until the spool is
+// empty or a batch fails with a temporary error. Spooled items may
+// be sent more than once, for example when only a part of the batch
+// was delivered. Items which can never be sent are dropped.
+func (b *batcher[T]) replay(ctx context.Context) error {
+	var errs []error
+	for !b.spool.empty() {
+		records, end, err := b.spool.read(b.opts.BatchSize)
+		if err != nil {
+			return errors.Join(append(errs, fmt.Errorf("reading spool: %w", err))...)
+		}
+		if len(records) == 0 {
+			break
+		}
+		batch := make([]T, 0, len(records))
+		for _, record := range records {
+			item, err := b.codec.decode(record)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("dropped spooled record: %w", err))
+				continue
+			}
+			batch = append(batch, item)
+		}
+		if len(batch) > 0 {
+			if err = b.send(ctx, batch); err != nil {
+				if len(undelivered(batch, err)) > 0 {
+					break
+				}
+				errs = append(errs, err)
+			}
+		}
+		if err = b.spool.commit(end); err != nil {
+			return errors.Join(append(errs, fmt.Errorf("committing spool: %w", err))...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush sends all buffered items.
+func (b *batcher[T]) Flush(ctx context.Context) error {
+	return b.flush(ctx)
+}
+
+// Close stops the background goroutine and
+// sends the remaining items.
+// If ctx is done first, a running background flush is canceled
+// and the remaining items are dropped.
+func (b *batcher[T]) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	b.closed = true
+	b.mu.Unlock()
+	close(b.done)
+	defer b.cancel()
+	select {
+	case <-b.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	err := b.flush(ctx)
+	if b.spool != nil {
+		if lockErr := b.lock(ctx); lockErr != nil {
+			return errors.Join(err, lockErr)
+		}
+		defer b.unlock()
+		err = errors.Join(err, b.spool.close())
+	}
+	return err
+}
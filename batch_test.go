@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSender records the batches passed to send.
+type testSender struct {
+	mu      sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (s *testSender) send(_ context.Context, batch []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, batch)
+	return s.err
+}
+
+func (s *testSender) get() [][]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batches
+}
+
+func Test_batcher_size(t *testing.T) {
+	sender := new(testSender)
+	b := newBatcher(BatchOptions{BatchSize: 2, FlushInterval: time.Hour}, sender.send, nil)
+	for i := range 5 {
+		require.NoError(t, b.add(i))
+	}
+	assert.Eventually(t, func() bool {
+		return len(sender.get()) >= 2
+	}, time.Second, time.Millisecond)
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, [][]int{{0, 1}, {2, 3}, {4}}, sender.get())
+	assert.ErrorIs(t, b.add(5), ErrClosed)
+	assert.ErrorIs(t, b.Close(context.Background()), ErrClosed)
+}
+
+func Test_batcher_interval(t *testing.T) {
+	sender := new(testSender)
+	b := newBatcher(BatchOptions{BatchSize: 100, FlushInterval: time.Millisecond}, sender.send, nil)
+	defer b.Close(context.Background())
+	require.NoError(t, b.add(1))
+	assert.Eventually(t, func() bool {
+		return len(sender.get()) == 1
+	}, time.Second, time.Millisecond)
+}
+
+func Test_batcher_dropAndErrors(t *testing.T) {
+	sender := &testSender{err: errors.New("unavailable")}
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	b := newBatcher(BatchOptions{
+		BatchSize:     2,
+		MaxBuffered:   3,
+		FlushInterval: time.Hour,
+		ErrorHandler: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	}, sender.send, nil)
+	require.NoError(t, b.lock(context.Background())) // block the background flush
+	for i := range 5 {
+		require.NoError(t, b.add(i))
+	}
+	b.unlock()
+
+	// the background goroutine might have sent before Close.
+	closeErr := b.Close(context.Background())
+	mu.Lock()
+	err := errors.Join(append(errs, closeErr)...)
+	mu.Unlock()
+	assert.ErrorContains(t, err, "unavailable")
+	assert.ErrorContains(t, err, "dropped 2 records")
+	var got []int
+	for _, batch := range sender.get() {
+		got = append(got, batch...)
+	}
+	assert.Equal(t, []int{2, 3, 4}, got)
+}
+
+func Test_batcher_context(t *testing.T) {
+	sending := make(chan struct{})
+	var canceled atomic.Bool
+	b := newBatcher(BatchOptions{BatchSize: 1, FlushInterval: time.Hour, ErrorHandler: func(error) {}},
+		func(ctx context.Context, _ []int) error {
+			close(sending)
+			<-ctx.Done()
+			canceled.Store(true)
+			return ctx.Err()
+		}, nil)
+	require.NoError(t, b.add(1))
+	<-sending
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.Flush(ctx), context.DeadlineExceeded)
+	assert.False(t, canceled.Load())
+	assert.ErrorIs(t, b.Close(ctx), context.DeadlineExceeded)
+	assert.Eventually(t, canceled.Load, time.Second, time.Millisecond)
+}
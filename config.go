@@ -1,8 +1,10 @@
 package logging
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
@@ -14,6 +16,29 @@ type Config struct {
 	Formatter   formatter `json:"formatter"`
 	LocalLogger bool      `json:"localLogger"`
 	AddSource   bool      `json:"addSource"`
+	Output      Output    `json:"output"`
+	// Async passes the records through a bounded queue,
+	// so slow outputs don't block the caller.
+	// See [AsyncHandler] and [AsyncWriter].
+	Async *AsyncOptions `json:"async"`
+	// Outputs replace the Output by several outputs,
+	// each with its own level, format and filter.
+	// See [OutputConfig].
+	Outputs []OutputConfig `json:"outputs"`
+
+	// sink is shared by the logrus logger and the slog loggers.
+	sink Sink
+	// hook sends the entries of the logger to the sink
+	// or the async writer, instead of its previous output out.
+	hook    logrus.Hook
+	out     io.Writer
+	closers []closer
+}
+
+// closer is implemented by the sinks and async
+// outputs created from the config.
+type closer interface {
+	Close(ctx context.Context) error
 }
 
 type formatter struct {
@@ -29,7 +54,7 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err != nil {
 		return err
 	}
-	return c.SetLogger()
+	return c.setLogger()
 }
 
 func (c *Config) UnmarshalJSON(data []byte) error {
@@ -38,26 +63,47 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
-	return c.SetLogger()
+	return c.setLogger()
 }
 
+// SetLogger configures the logger with the formatter, level and outputs.
+// Outputs other than stderr are created on the first call of SetLogger
+// or [Config.Slog] and shared by both, until [Config.Close] is called.
+// Unmarshaling the config only sets the formatter and level.
 func (c *Config) SetLogger() (err error) {
-	err = c.parseFormatter()
+	err = c.setFormatter()
 	if err != nil {
 		return err
 	}
-	err = c.parseLevel()
+	err = c.setOutput()
 	if err != nil {
 		return err
 	}
-	err = c.unmarshalFormatter()
-	if err != nil {
+	c.setGlobal()
+	return nil
+}
+
+// setLogger configures the logger without the outputs.
+func (c *Config) setLogger() error {
+	if err := c.setFormatter(); err != nil {
 		return err
 	}
 	c.setGlobal()
 	return nil
 }
 
+func (c *Config) setFormatter() (err error) {
+	err = c.parseFormatter()
+	if err != nil {
+		return err
+	}
+	err = c.parseLevel()
+	if err != nil {
+		return err
+	}
+	return c.unmarshalFormatter()
+}
+
 func (c *Config) setGlobal() {
 	if c.LocalLogger {
 		return
@@ -65,6 +111,10 @@ func (c *Config) setGlobal() {
 	logrus.SetFormatter(log.Formatter)
 	logrus.SetLevel(log.Level)
 	logrus.SetReportCaller(log.ReportCaller)
+	if len(c.closers) > 0 {
+		logrus.SetOutput(log.Out)
+		logrus.StandardLogger().ReplaceHooks(log.Hooks)
+	}
 	log = (*logger)(logrus.StandardLogger())
 }
 
@@ -81,23 +131,58 @@ func (c *Config) parseLevel() error {
 		log.Level = logrus.InfoLevel
 		return nil
 	}
-	level, err := logrus.ParseLevel(c.Level)
+	level, err := parseLevel(c.Level)
 	if err != nil {
 		return err
 	}
-	log.Level = level
+	log.Level = logrusLevel(level)
 	return nil
 }
 
+// parseLevel accepts the slog level names, like "INFO+2",
+// and the logrus level names, like "warning" or "trace",
+// for the level of the config and of the outputs.
+func parseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err == nil {
+		return level, nil
+	}
+	l, err := logrus.ParseLevel(s)
+	if err != nil {
+		return 0, fmt.Errorf("not a valid level: %q", s)
+	}
+	return slogLevel(l), nil
+}
+
 const (
-	FormatterText = "text"
-	FormatterJSON = "json"
+	FormatterText   = "text"
+	FormatterJSON   = "json"
+	FormatterLogfmt = "logfmt"
+	// FormatterConsole is a colored, human friendly format
+	// for development. See [NewConsoleHandler].
+	FormatterConsole = "console"
+	// FormatterPretty is an alias for [FormatterConsole].
+	FormatterPretty = "pretty"
+	// FormatterGCP is JSON in the Google Cloud Logging schema.
+	// See [NewGCPHandler].
+	FormatterGCP = "gcp"
+	// FormatterECS is JSON in the Elastic Common Schema.
+	// See [NewECSHandler].
+	FormatterECS = "ecs"
 )
 
 func (c *Config) parseFormatter() error {
 	switch c.Formatter.Format {
 	case FormatterJSON:
 		log.Formatter = &logrus.JSONFormatter{}
+	case FormatterLogfmt:
+		log.Formatter = &LogfmtFormatter{}
+	case FormatterConsole, FormatterPretty:
+		log.Formatter = &logrus.TextFormatter{FullTimestamp: true}
+	case FormatterGCP:
+		log.Formatter = &GCPFormatter{}
+	case FormatterECS:
+		log.Formatter = &ECSFormatter{}
 	case FormatterText, "":
 		log.Formatter = &logrus.TextFormatter{}
 	default:
@@ -110,28 +195,74 @@ func (c *Config) parseFormatter() error {
 func (c *Config) Slog() *slog.Logger {
 	logger := slog.Default()
 
-	var level slog.Level
-	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+	level, err := parseLevel(c.Level)
+	if err != nil {
 		logger.Warn("invalid config, using default slog", "err", err)
 		return logger
 	}
-	opts := &slog.HandlerOptions{
+	opts := c.handlerOptions(level)
+	if c.Async != nil {
+		if err := c.Async.validate(); err != nil {
+			logger.Warn("invalid async options in config, using drop policy newest", "err", err)
+		}
+	}
+	sink, err := c.outputSink(opts)
+	if err != nil {
+		logger.Warn("invalid output in config, using stderr", "err", err)
+	}
+	if sink != nil {
+		return slog.New(sink)
+	}
+	handler := c.stderrHandler(logger, opts)
+	if c.Async != nil {
+		async := NewAsyncHandler(handler, *c.Async)
+		c.closers = append(c.closers, async)
+		return slog.New(async)
+	}
+	return slog.New(handler)
+}
+
+func (c *Config) handlerOptions(level slog.Leveler) *slog.HandlerOptions {
+	return &slog.HandlerOptions{
 		AddSource:   c.AddSource,
 		Level:       level,
 		ReplaceAttr: c.fieldMapToPlaceKey(),
 	}
+}
 
-	switch c.Formatter.Format {
-	case FormatterText:
-		return slog.New(slog.NewTextHandler(os.Stderr, opts))
-	case FormatterJSON:
-		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
-	case "":
+// stderrHandler creates the handler for the formatter,
+// writing to stderr.
+func (c *Config) stderrHandler(logger *slog.Logger, opts *slog.HandlerOptions) slog.Handler {
+	if c.Formatter.Format == "" {
 		logger.Warn("no slog format in config, using text handler")
-	default:
+	}
+	handler, err := c.formatHandler(c.Formatter.Format, opts)
+	if err != nil {
 		logger.Warn("unknown slog format in config, using text handler", "format", c.Formatter.Format)
+		return slog.NewTextHandler(os.Stderr, opts)
+	}
+	return handler
+}
+
+// formatHandler creates the handler for the format, writing to stderr.
+// An empty format is text.
+func (c *Config) formatHandler(format string, opts *slog.HandlerOptions) (slog.Handler, error) {
+	switch format {
+	case FormatterText, "":
+		return slog.NewTextHandler(os.Stderr, opts), nil
+	case FormatterJSON:
+		return slog.NewJSONHandler(os.Stderr, opts), nil
+	case FormatterLogfmt:
+		return NewLogfmtHandler(os.Stderr, opts), nil
+	case FormatterConsole, FormatterPretty:
+		return NewConsoleHandler(os.Stderr, c.consoleOptions(opts)), nil
+	case FormatterGCP:
+		return NewGCPHandler(os.Stderr, c.gcpOptions(opts)), nil
+	case FormatterECS:
+		return NewECSHandler(os.Stderr, opts), nil
+	default:
+		return nil, fmt.Errorf("%s formatter not supported", format)
 	}
-	return slog.New(slog.NewTextHandler(os.Stderr, opts))
 }
 
 func (c *Config) fieldMapToPlaceKey() func(groups []string, a slog.Attr) slog.Attr {
@@ -149,3 +280,38 @@ func (c *Config) fieldMapToPlaceKey() func(groups []string, a slog.Attr) slog.At
 		return a
 	}
 }
+
+func (c *Config) consoleOptions(opts *slog.HandlerOptions) *ConsoleHandlerOptions {
+	var data struct {
+		TimeFormat    string `json:"timeFormat"`
+		ForceColors   bool   `json:"forceColors"`
+		DisableColors bool   `json:"disableColors"`
+	}
+	c.formatterData(&data)
+	return &ConsoleHandlerOptions{
+		HandlerOptions: *opts,
+		TimeFormat:     data.TimeFormat,
+		ForceColors:    data.ForceColors,
+		DisableColors:  data.DisableColors,
+	}
+}
+
+func (c *Config) gcpOptions(opts *slog.HandlerOptions) *GCPHandlerOptions {
+	var data struct {
+		ProjectID string `json:"projectID"`
+	}
+	c.formatterData(&data)
+	return &GCPHandlerOptions{
+		HandlerOptions: *opts,
+		ProjectID:      data.ProjectID,
+	}
+}
+
+// formatterData unmarshals the formatter data into v.
+// Unknown keys and invalid data are ignored,
+// as the data is shared with the logrus formatters.
+func (c *Config) formatterData(v any) {
+	if raw, err := json.Marshal(c.Formatter.Data); err == nil {
+		_ = json.Unmarshal(raw, v)
+	}
+}
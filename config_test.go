@@ -2,6 +2,7 @@ package logging
 
 import (
 	"encoding/json"
+	"log/slog"
 	"reflect"
 	"testing"
 
@@ -35,11 +36,31 @@ func TestUnmarshalJSON(t *testing.T) {
 			[]byte(`{"level": "warn", "formatter":{"format": "text", "data": {"forceColors": true}}}`),
 			expected{false, logrus.WarnLevel, &logrus.TextFormatter{ForceColors: true}},
 		},
+		{
+			"info level logfmt format",
+			[]byte(`{"level": "info", "formatter":{"format": "logfmt", "data": {"disableTimestamp": true}}}`),
+			expected{false, logrus.InfoLevel, &LogfmtFormatter{}},
+		},
+		{
+			"info level gcp format",
+			[]byte(`{"level": "info", "formatter":{"format": "gcp", "data": {"projectID": "my-project"}}}`),
+			expected{false, logrus.InfoLevel, &GCPFormatter{}},
+		},
+		{
+			"info level ecs format",
+			[]byte(`{"level": "info", "formatter":{"format": "ecs"}}`),
+			expected{false, logrus.InfoLevel, &ECSFormatter{}},
+		},
 		{
 			"warn level default format",
 			[]byte(`{"level": "error"}`),
 			expected{false, logrus.ErrorLevel, &logrus.TextFormatter{}},
 		},
+		{
+			"slog level",
+			[]byte(`{"level": "INFO+2"}`),
+			expected{false, logrus.InfoLevel, &logrus.TextFormatter{}},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -102,6 +123,17 @@ formatter:
 `),
 			expected{false, logrus.WarnLevel, &logrus.TextFormatter{ForceColors: true}},
 		},
+		{
+			"info level logfmt format",
+			[]byte(`
+level: info
+formatter:
+  format: logfmt
+  data:
+    disableTimestamp: true
+`),
+			expected{false, logrus.InfoLevel, &LogfmtFormatter{DisableTimestamp: true}},
+		},
 		{
 			"warn level default format",
 			[]byte(`level: error`),
@@ -124,3 +156,32 @@ formatter:
 		})
 	}
 }
+
+func Test_parseLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"INFO+2", slog.LevelInfo + 2, false},
+		{"warn", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{"trace", slog.LevelDebug - 4, false},
+		{"fatal", slog.LevelError + 4, false},
+		{"loud", 0, true},
+		{"", 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.level, func(t *testing.T) {
+			got, err := parseLevel(test.level)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("expected level \"%s\" got \"%s\"", test.want, got)
+			}
+		})
+	}
+}
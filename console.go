@@ -0,0 +1,234 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ConsoleHandlerOptions are options for [NewConsoleHandler].
+type ConsoleHandlerOptions struct {
+	slog.HandlerOptions
+
+	// TimeFormat is used for the time of the record,
+	// defaults to "15:04:05.000".
+	TimeFormat string
+	// ForceColors enables colors, even if the output is not a terminal
+	// or the NO_COLOR environment variable is set.
+	ForceColors bool
+	// DisableColors disables colors.
+	DisableColors bool
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiFaint  = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// NewConsoleHandler creates a [slog.Handler] for humans reading
+// logs during development.
+// Each line starts with the time, level and message,
+// followed by the attributes. Groups are printed
+// indented on the following lines.
+// Source paths are shortened to the directory and file name.
+//
+// Colors are used if w is a terminal, unless the NO_COLOR
+// environment variable is set. This can be overridden by options.
+// If opts is nil, the default options are used.
+func NewConsoleHandler(w io.Writer, opts *ConsoleHandlerOptions) slog.Handler {
+	if opts == nil {
+		opts = new(ConsoleHandlerOptions)
+	}
+	h := &consoleHandler{
+		baseHandler: newBaseHandler(&opts.HandlerOptions),
+		mu:          new(sync.Mutex),
+		w:           w,
+		timeFormat:  opts.TimeFormat,
+		color:       opts.ForceColors || (!opts.DisableColors && isTerminal(w) && os.Getenv("NO_COLOR") == ""),
+	}
+	if h.timeFormat == "" {
+		h.timeFormat = "15:04:05.000"
+	}
+	return h
+}
+
+type consoleHandler struct {
+	baseHandler
+	mu         *sync.Mutex
+	w          io.Writer
+	timeFormat string
+	color      bool
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.baseHandler = h.withAttrs(attrs)
+	return &h2
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.baseHandler = h.withGroup(name)
+	return &h2
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	b := make([]byte, 0, 256)
+	if !r.Time.IsZero() {
+		if a, ok := h.builtinAttr(slog.Time(slog.TimeKey, r.Time)); ok {
+			b = h.appendColored(b, ansiFaint, h.formatTime(a.Value))
+			b = append(b, ' ')
+		}
+	}
+	if a, ok := h.builtinAttr(slog.Any(slog.LevelKey, r.Level)); ok {
+		level := a.Value.String()
+		for len(level) < 5 {
+			level += " "
+		}
+		b = h.appendColored(b, levelColor(r.Level), level)
+		b = append(b, ' ')
+	}
+	if a, ok := h.builtinAttr(slog.String(slog.MessageKey, r.Message)); ok {
+		b = append(b, a.Value.String()...)
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		if a, ok := h.builtinAttr(slog.String(slog.SourceKey, shortSource(recordSource(r)))); ok {
+			b = append(b, ' ')
+			b = h.appendColored(b, ansiFaint, a.Value.String())
+		}
+	}
+
+	root := new(consoleGroup)
+	for _, a := range h.recordAttrs(r) {
+		g := root
+		for _, name := range a.groups {
+			g = g.group(name)
+		}
+		g.attrs = append(g.attrs, a.attr)
+	}
+	for _, a := range root.attrs {
+		b = append(b, ' ')
+		b = h.appendAttr(b, a)
+	}
+	for _, g := range root.groups {
+		b = h.appendGroup(b, g, 1)
+	}
+	b = append(b, '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(b)
+	return err
+}
+
+func (h *consoleHandler) formatTime(v slog.Value) string {
+	if v.Kind() == slog.KindTime {
+		return v.Time().Format(h.timeFormat)
+	}
+	return v.String()
+}
+
+// consoleGroup holds the attributes of a group, so each group
+// is printed once, even if its attributes were added at
+// different times with WithAttrs and WithGroup.
+type consoleGroup struct {
+	name   string
+	attrs  []slog.Attr
+	groups []*consoleGroup
+}
+
+// group returns the subgroup name, which is added if missing.
+func (g *consoleGroup) group(name string) *consoleGroup {
+	for _, sub := range g.groups {
+		if sub.name == name {
+			return sub
+		}
+	}
+	sub := &consoleGroup{name: name}
+	g.groups = append(g.groups, sub)
+	return sub
+}
+
+// appendGroup appends the group name and its attributes
+// and subgroups indented on the following lines.
+func (h *consoleHandler) appendGroup(b []byte, g *consoleGroup, depth int) []byte {
+	b = appendIndent(b, depth)
+	b = h.appendColored(b, ansiCyan, g.name+":")
+	for _, a := range g.attrs {
+		b = appendIndent(b, depth+1)
+		b = h.appendAttr(b, a)
+	}
+	for _, sub := range g.groups {
+		b = h.appendGroup(b, sub, depth+1)
+	}
+	return b
+}
+
+// appendAttr appends key=value in logfmt, with the key colored.
+func (h *consoleHandler) appendAttr(b []byte, a slog.Attr) []byte {
+	// the key is escaped by logfmt, so the first "=" separates the value.
+	pair := appendLogfmtValue(nil, a.Key, a.Value)
+	i := bytes.IndexByte(pair, '=')
+	b = h.appendColored(b, ansiCyan, string(pair[:i]))
+	return append(b, pair[i:]...)
+}
+
+func (h *consoleHandler) appendColored(b []byte, color, s string) []byte {
+	if !h.color {
+		return append(b, s...)
+	}
+	b = append(b, color...)
+	b = append(b, s...)
+	return append(b, ansiReset...)
+}
+
+func appendIndent(b []byte, depth int) []byte {
+	b = append(b, '\n')
+	for range depth {
+		b = append(b, "  "...)
+	}
+	return b
+}
+
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return ansiRed
+	case level >= slog.LevelWarn:
+		return ansiYellow
+	case level >= slog.LevelInfo:
+		return ansiGreen
+	default:
+		return ansiBlue
+	}
+}
+
+// shortSource shortens "/path/to/dir/file.go:12" to "dir/file.go:12".
+func shortSource(source string) string {
+	dir, file := filepath.Split(source)
+	return filepath.Join(filepath.Base(dir), file)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
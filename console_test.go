@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *ConsoleHandlerOptions
+		time    time.Time
+		level   slog.Level
+		handler func(slog.Handler) slog.Handler
+		attrs   []slog.Attr
+		want    string
+	}{
+		{
+			name:  "plain",
+			level: slog.LevelInfo,
+			attrs: []slog.Attr{slog.String("key", "a value"), slog.Int("n", 1)},
+			want:  `INFO  request served key="a value" n=1` + "\n",
+		},
+		{
+			name:  "time",
+			time:  time.Date(2026, 10, 17, 12, 1, 2, 3e6, time.UTC),
+			level: slog.LevelWarn,
+			want:  "12:01:02.003 WARN  request served\n",
+		},
+		{
+			name:  "groups",
+			level: slog.LevelDebug,
+			opts:  &ConsoleHandlerOptions{HandlerOptions: slog.HandlerOptions{Level: slog.LevelDebug}},
+			handler: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.String("id", "id1")}).WithGroup("http")
+			},
+			attrs: []slog.Attr{
+				slog.Duration("duration", time.Second),
+				slog.Group("request", slog.String("method", "GET"), slog.Group("url", slog.String("path", "/"))),
+				slog.Group("response", slog.Int("status", 200)),
+			},
+			want: `DEBUG request served id=id1
+  http:
+    duration=1s
+    request:
+      method=GET
+      url:
+        path=/
+    response:
+      status=200
+`,
+		},
+		{
+			name:  "interleaved groups",
+			level: slog.LevelInfo,
+			handler: func(h slog.Handler) slog.Handler {
+				return h.WithGroup("http").
+					WithAttrs([]slog.Attr{slog.Group("request", slog.String("method", "GET"))}).
+					WithAttrs([]slog.Attr{slog.String("id", "id1")})
+			},
+			attrs: []slog.Attr{slog.Group("request", slog.String("path", "/"))},
+			want: `INFO  request served
+  http:
+    id=id1
+    request:
+      method=GET
+      path=/
+`,
+		},
+		{
+			name: "replace attr",
+			time: time.Date(2026, 10, 17, 12, 1, 2, 3e6, time.UTC),
+			opts: &ConsoleHandlerOptions{HandlerOptions: slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					switch a.Key {
+					case slog.TimeKey:
+						return slog.Attr{}
+					case slog.LevelKey:
+						return slog.String(a.Key, "I")
+					case slog.MessageKey:
+						return slog.String(a.Key, strings.ToUpper(a.Value.String()))
+					}
+					return a
+				},
+			}},
+			level: slog.LevelInfo,
+			attrs: []slog.Attr{slog.Int("n", 1)},
+			want:  "I     REQUEST SERVED n=1\n",
+		},
+		{
+			name:  "colors",
+			opts:  &ConsoleHandlerOptions{ForceColors: true},
+			level: slog.LevelError,
+			attrs: []slog.Attr{slog.Group("g", slog.Int("n", 1))},
+			want:  "\x1b[31mERROR\x1b[0m request served\n  \x1b[36mg:\x1b[0m\n    \x1b[36mn\x1b[0m=1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(strings.Builder)
+			var h slog.Handler = NewConsoleHandler(out, tt.opts)
+			if tt.handler != nil {
+				h = tt.handler(h)
+			}
+			r := slog.NewRecord(tt.time, tt.level, "request served", 0)
+			r.AddAttrs(tt.attrs...)
+			require.NoError(t, h.Handle(context.Background(), r))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestConsoleHandler_source(t *testing.T) {
+	out := new(strings.Builder)
+	logger := slog.New(NewConsoleHandler(out, &ConsoleHandlerOptions{
+		HandlerOptions: slog.HandlerOptions{AddSource: true},
+	}))
+	logger.Info("hello")
+	assert.Regexp(t, `^\d\d:\d\d:\d\d\.\d{3} INFO  hello [\w-]+/console_test\.go:\d+\n$`, out.String())
+}
+
+func Test_isTerminal(t *testing.T) {
+	assert.False(t, isTerminal(new(strings.Builder)))
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	require.NoError(t, err)
+	defer f.Close()
+	assert.False(t, isTerminal(f))
+}
+
+func Test_shortSource(t *testing.T) {
+	assert.Equal(t, "logging/console.go:12", shortSource("/home/user/logging/console.go:12"))
+	assert.Equal(t, "console.go:12", shortSource("console.go:12"))
+}
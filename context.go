@@ -21,3 +21,39 @@ func FromContext(ctx context.Context) (logger *slog.Logger, ok bool) {
 func ToContext(ctx context.Context, logger *slog.Logger) context.Context {
 	return context.WithValue(ctx, ctxKey, logger)
 }
+
+type noClientLoggingKeyType struct{}
+
+var noClientLoggingKey noClientLoggingKeyType
+
+// WithoutClientLogging marks the context so that requests made
+// with it are not logged by a client enabled with [EnableHTTPClient].
+func WithoutClientLogging(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noClientLoggingKey, struct{}{})
+}
+
+// ClientLoggingDisabled reports if the context was passed
+// to [WithoutClientLogging]. Clients of other protocols,
+// like the interceptors of the grpclogging package, use it
+// to skip logging as well.
+func ClientLoggingDisabled(ctx context.Context) bool {
+	return ctx.Value(noClientLoggingKey) != nil
+}
+
+type httpRecordKeyType struct{}
+
+var httpRecordKey httpRecordKeyType
+
+// withHTTPRecord marks the context of the records logged by [Middleware]
+// and [EnableHTTPClient], which have their attributes in group.
+// This allows handlers like [NewECSHandler] to map the attributes
+// without mistaking other "request" attributes for them.
+func withHTTPRecord(ctx context.Context, group string) context.Context {
+	return context.WithValue(ctx, httpRecordKey, group)
+}
+
+// httpRecordGroup returns the group passed to [withHTTPRecord].
+func httpRecordGroup(ctx context.Context) (group string, ok bool) {
+	group, ok = ctx.Value(httpRecordKey).(string)
+	return group, ok
+}
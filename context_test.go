@@ -20,3 +20,9 @@ func TestContext(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, want, got)
 }
+
+func TestWithoutClientLogging(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, ClientLoggingDisabled(ctx))
+	assert.True(t, ClientLoggingDisabled(WithoutClientLogging(ctx)))
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const ecsVersion = "8.11.0"
+
+// NewECSHandler creates a [slog.Handler] which writes JSON in the
+// Elastic Common Schema (ECS).
+//
+//   - The time is written as "@timestamp", the level as "log.level"
+//     and the message as "message".
+//   - The source is written as "log.origin".
+//   - "trace_id", "span_id" and "error" attributes are written as
+//     "trace.id", "span.id" and "error.message".
+//   - The "request", "response" and "duration" attributes produced by
+//     [Middleware] and [EnableHTTPClient] are written as
+//     "http.request.method", "url.full", "http.response.status_code",
+//     "http.response.body.bytes" and "event.duration".
+//
+// ReplaceAttr is not called for the built-in attributes.
+// If opts is nil, the default options are used.
+func NewECSHandler(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	return newJSONProfileHandler(w, opts, ecsProfile)
+}
+
+// ECSFormatter formats logrus entries like [NewECSHandler].
+type ECSFormatter struct{}
+
+// Format implements [logrus.Formatter].
+func (f *ECSFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	return formatJSONProfile(entry, ecsProfile)
+}
+
+func ecsProfile(r *jsonRecord) map[string]any {
+	m := r.attrs
+	m["@timestamp"] = r.time.UTC().Format(time.RFC3339Nano)
+	if r.time.IsZero() {
+		delete(m, "@timestamp")
+	}
+	m["log.level"] = ecsLevel(r.level)
+	m["message"] = r.message
+	m["ecs.version"] = ecsVersion
+	if r.source != nil {
+		m["log.origin"] = map[string]any{
+			"file": map[string]any{
+				"name": r.source.File,
+				"line": r.source.Line,
+			},
+			"function": r.source.Function,
+		}
+	}
+	moveValue(m, []string{"trace_id"}, "trace", "id")
+	moveValue(m, []string{"span_id"}, "span", "id")
+	moveValue(m, []string{"error"}, "error", "message")
+
+	if r.http == nil {
+		return m
+	}
+	moveValue(m, r.httpPath("request", "method"), "http", "request", "method")
+	moveValue(m, r.httpPath("request", "url"), "url", "full")
+	if status, ok := popValue(m, r.httpPath("response", "status")...); ok {
+		setValue(m, slog.Int64Value(statusCode(status)), "http", "response", "status_code")
+	}
+	moveValue(m, r.httpPath("response", "written"), "http", "response", "body", "bytes")
+	if size, ok := popValue(m, r.httpPath("response", "content_length")...); ok && size.Kind() == slog.KindInt64 && size.Int64() >= 0 {
+		setValue(m, size, "http", "response", "body", "bytes")
+	}
+	moveValue(m, r.httpPath("duration"), "event", "duration")
+	return m
+}
+
+// moveValue moves the value at from to the path to.
+func moveValue(m map[string]any, from []string, to ...string) {
+	if v, ok := popValue(m, from...); ok {
+		setValue(m, v, to...)
+	}
+}
+
+// setValue sets v at path in m, creating
+// or replacing intermediate maps as needed.
+func setValue(m map[string]any, v slog.Value, path ...string) {
+	for _, key := range path[:len(path)-1] {
+		sub, ok := m[key].(map[string]any)
+		if !ok {
+			sub = make(map[string]any)
+			m[key] = sub
+		}
+		m = sub
+	}
+	m[path[len(path)-1]] = v
+}
+
+func ecsLevel(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "debug"
+	case level < slog.LevelWarn:
+		return "info"
+	case level < slog.LevelError:
+		return "warn"
+	case level < slog.LevelError+4:
+		return "error"
+	case level < slog.LevelError+8:
+		return "fatal"
+	default:
+		return "panic"
+	}
+}
@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestECSHandler_middleware(t *testing.T) {
+	out := new(strings.Builder)
+	logger := slog.New(NewECSHandler(out, nil))
+	mw := Middleware(
+		WithLogger(logger.With("trace_id", "abc")),
+		WithDurationFunc(func(time.Time) time.Duration {
+			return time.Second
+		}),
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Hello, World!")
+	})
+	mw(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "https://example.com/path/", nil))
+
+	got := out.String()
+	assert.Regexp(t, `"@timestamp":"\d{4}-\d\d-\d\dT[^"]+Z"`, got)
+	assert.JSONEq(t, `{
+		"@timestamp":"ignored",
+		"log.level":"info",
+		"message":"request served",
+		"ecs.version":"8.11.0",
+		"trace":{"id":"abc"},
+		"http":{
+			"request":{"method":"GET"},
+			"response":{"status_code":200,"body":{"bytes":13}}
+		},
+		"url":{"full":"https://example.com/path/"},
+		"event":{"duration":1000000000}
+	}`, replaceJSONField(t, got, "@timestamp", "ignored"))
+}
+
+func TestECSHandler_client(t *testing.T) {
+	out := new(strings.Builder)
+	logger := slog.New(NewECSHandler(out, nil))
+	c := &http.Client{Transport: errRountripper{}}
+	EnableHTTPClient(c,
+		WithFallbackLogger(logger),
+		WithClientDurationFunc(func(time.Time) time.Duration {
+			return time.Second
+		}),
+	)
+	_, err := c.Get("http://example.com/")
+	require.Error(t, err)
+
+	assert.JSONEq(t, `{
+		"@timestamp":"ignored",
+		"log.level":"error",
+		"message":"request roundtrip",
+		"ecs.version":"8.11.0",
+		"error":{"message":"io: read/write on closed pipe"},
+		"http":{"request":{"method":"GET"}},
+		"url":{"full":"http://example.com/"},
+		"event":{"duration":1000000000}
+	}`, replaceJSONField(t, out.String(), "@timestamp", "ignored"))
+}
+
+func TestECSFormatter(t *testing.T) {
+	logger := logrus.New()
+	out := new(bytes.Buffer)
+	logger.SetOutput(out)
+	logger.SetFormatter(&ECSFormatter{})
+	logger.WithError(errors.New("oops")).
+		WithField("caller", "/src/main.go:12").
+		WithTime(time.Date(2026, 10, 17, 12, 0, 0, 0, time.FixedZone("", 3600))).
+		Warn("careful")
+
+	assert.JSONEq(t, `{
+		"@timestamp":"2026-10-17T11:00:00Z",
+		"log.level":"warn",
+		"message":"careful",
+		"ecs.version":"8.11.0",
+		"error":{"message":"oops"},
+		"log.origin":{"file":{"name":"/src/main.go","line":12},"function":""}
+	}`, out.String())
+}
+
+func TestECSHandler_contentLength(t *testing.T) {
+	out := new(strings.Builder)
+	logger := slog.New(NewECSHandler(out, nil))
+	logger.InfoContext(withHTTPRecord(context.Background(), ""), "request",
+		slog.Group("request", "method", "GET", "url", "http://example.com/"),
+		slog.Group("response", "content_length", "unknown"),
+	)
+	assert.JSONEq(t, `{
+		"@timestamp":"ignored",
+		"log.level":"info",
+		"message":"request",
+		"ecs.version":"8.11.0",
+		"http":{"request":{"method":"GET"}},
+		"url":{"full":"http://example.com/"}
+	}`, replaceJSONField(t, out.String(), "@timestamp", "ignored"))
+}
+
+func TestECSHandler_middlewareGroup(t *testing.T) {
+	out := new(strings.Builder)
+	logger := slog.New(NewECSHandler(out, nil)).WithGroup("app")
+	mw := Middleware(
+		WithLogger(logger),
+		WithGroup("http"),
+		WithDurationFunc(func(time.Time) time.Duration {
+			return time.Second
+		}),
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mw(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "https://example.com/path/", nil))
+
+	assert.JSONEq(t, `{
+		"@timestamp":"ignored",
+		"log.level":"info",
+		"message":"request served",
+		"ecs.version":"8.11.0",
+		"http":{
+			"request":{"method":"GET"},
+			"response":{"status_code":204,"body":{"bytes":0}}
+		},
+		"url":{"full":"https://example.com/path/"},
+		"event":{"duration":1000000000}
+	}`, replaceJSONField(t, out.String(), "@timestamp", "ignored"))
+}
+
+func TestECSHandler_grpc(t *testing.T) {
+	out := new(strings.Builder)
+	logger := slog.New(NewECSHandler(out, nil))
+	// the attributes logged by the gRPC interceptors.
+	logger.Info("request served",
+		slog.Group("request", "method", "/test.Service/Get", "peer", "bufconn"),
+		slog.Group("response", "code", "OK"),
+		slog.Duration("duration", time.Second),
+	)
+	assert.JSONEq(t, `{
+		"@timestamp":"ignored",
+		"log.level":"info",
+		"message":"request served",
+		"ecs.version":"8.11.0",
+		"request":{"method":"/test.Service/Get","peer":"bufconn"},
+		"response":{"code":"OK"},
+		"duration":1000000000
+	}`, replaceJSONField(t, out.String(), "@timestamp", "ignored"))
+}
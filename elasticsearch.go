@@ -0,0 +1,301 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ElasticsearchConfig configures the [ElasticsearchHandler].
+type ElasticsearchConfig struct {
+	// URL of the cluster, defaults to "http://localhost:9200".
+	URL string `json:"url"`
+	// Index the documents are written to. A Go time layout in braces
+	// is replaced by the UTC time of the record, like in the default
+	// "logs-{2006.01.02}".
+	Index string `json:"index"`
+	// Username and Password are used for basic authentication.
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// APIKey is the base64 encoded API key,
+	// used instead of basic authentication.
+	APIKey string `json:"apiKey" yaml:"apiKey"`
+	// Client is used to send requests,
+	// defaults to a client with a timeout of 10 seconds.
+	Client *http.Client `json:"-" yaml:"-"`
+
+	BatchOptions `yaml:",inline"`
+	RetryOptions `yaml:",inline"`
+}
+
+// ElasticsearchHandler is a [Sink] writing records as documents
+// into Elasticsearch or OpenSearch, using the _bulk API.
+//
+// Documents have the same structure as the output of
+// [slog.JSONHandler]. The handler also implements [io.Writer],
+// accepting JSON lines as written by [slog.JSONHandler] or the
+// logrus JSONFormatter, so it can be used as their output.
+// The "time" field of a line selects the date of the index.
+//
+// Requests rejected as a whole with a 429, 502, 503 or 504 status
+// code, as well as the individual documents rejected with these codes,
+// are sent again as configured by the RetryOptions. Documents failing
+// with other errors, like mapping errors, are dropped and reported to
+// the ErrorHandler of the BatchOptions.
+type ElasticsearchHandler struct {
+	baseHandler
+	exporter *esExporter
+}
+
+// NewElasticsearchHandler creates an [ElasticsearchHandler]
+// and starts the background goroutine sending batches.
+// If opts is nil, the default options are used.
+func NewElasticsearchHandler(config ElasticsearchConfig, opts *slog.HandlerOptions) *ElasticsearchHandler {
+	if config.URL == "" {
+		config.URL = "http://localhost:9200"
+	}
+	if config.Index == "" {
+		config.Index = "logs-{2006.01.02}"
+	}
+	if config.Client == nil {
+		config.Client = defaultSinkClient
+	}
+	config.RetryOptions = config.RetryOptions.withDefaults()
+	e := &esExporter{config: config}
+	e.batcher = newBatcher(config.BatchOptions, e.send, esSpoolCodec)
+	return &ElasticsearchHandler{
+		baseHandler: newBaseHandler(opts),
+		exporter:    e,
+	}
+}
+
+func (h *ElasticsearchHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &ElasticsearchHandler{baseHandler: h.withAttrs(attrs), exporter: h.exporter}
+}
+
+func (h *ElasticsearchHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &ElasticsearchHandler{baseHandler: h.withGroup(name), exporter: h.exporter}
+}
+
+func (h *ElasticsearchHandler) Handle(_ context.Context, r slog.Record) error {
+	doc := jsonValues(attrMap(h.recordAttrs(r)))
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	if a, ok := h.builtinAttr(slog.Time(slog.TimeKey, t)); ok {
+		doc[a.Key] = jsonValue(a.Value)
+	}
+	if a, ok := h.builtinAttr(slog.Any(slog.LevelKey, r.Level)); ok {
+		doc[a.Key] = a.Value.String()
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		source := &slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}
+		if a, ok := h.builtinAttr(slog.Any(slog.SourceKey, source)); ok {
+			doc[a.Key] = jsonValue(a.Value)
+		}
+	}
+	if a, ok := h.builtinAttr(slog.String(slog.MessageKey, r.Message)); ok {
+		doc[a.Key] = jsonValue(a.Value)
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return h.exporter.batcher.add(esDocument{
+		index: h.exporter.index(t),
+		body:  b,
+	})
+}
+
+// Write adds each JSON line in p as a document.
+// The "time" field, in RFC 3339 format, selects the index.
+// Without it, the current time is used.
+// Lines which are not a JSON object are rejected.
+func (h *ElasticsearchHandler) Write(p []byte) (int, error) {
+	var errs []error
+	for line := range bytes.Lines(p) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var doc struct {
+			Time any `json:"time"`
+		}
+		if err := json.Unmarshal(line, &doc); err != nil {
+			errs = append(errs, fmt.Errorf("logging: elasticsearch: invalid document: %w", err))
+			continue
+		}
+		t := time.Now()
+		if s, ok := doc.Time.(string); ok {
+			if parsed, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				t = parsed
+			}
+		}
+		errs = append(errs, h.exporter.batcher.add(esDocument{
+			index: h.exporter.index(t),
+			body:  bytes.Clone(line),
+		}))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends all buffered documents.
+func (h *ElasticsearchHandler) Flush(ctx context.Context) error {
+	return h.exporter.batcher.Flush(ctx)
+}
+
+// Close sends all buffered documents and stops
+// the background goroutine.
+func (h *ElasticsearchHandler) Close(ctx context.Context) error {
+	return h.exporter.batcher.Close(ctx)
+}
+
+type esDocument struct {
+	index string
+	body  []byte
+}
+
+// esSpoolCodec spools the index, followed
+// by a newline and the document.
+var esSpoolCodec = &spoolCodec[esDocument]{
+	encode: func(doc esDocument) ([]byte, error) {
+		b := make([]byte, 0, len(doc.index)+1+len(doc.body))
+		b = append(b, doc.index...)
+		b = append(b, '\n')
+		return append(b, doc.body...), nil
+	},
+	decode: func(b []byte) (esDocument, error) {
+		index, body, ok := bytes.Cut(b, []byte{'\n'})
+		if !ok {
+			return esDocument{}, errors.New("missing index")
+		}
+		return esDocument{index: string(index), body: body}, nil
+	},
+}
+
+type esExporter struct {
+	config  ElasticsearchConfig
+	batcher *batcher[esDocument]
+}
+
+// index replaces the time layout in braces.
+func (e *esExporter) index(t time.Time) string {
+	index := e.config.Index
+	start := strings.IndexByte(index, '{')
+	end := strings.LastIndexByte(index, '}')
+	if start < 0 || end < start {
+		return index
+	}
+	return index[:start] + t.UTC().Format(index[start+1:end]) + index[end+1:]
+}
+
+func (e *esExporter) send(ctx context.Context, docs []esDocument) error {
+	pending := docs
+	var rejected []error
+	err := e.config.retry(ctx, func() error {
+		failed, errs, err := e.bulk(ctx, pending)
+		if err != nil {
+			return err
+		}
+		rejected = append(rejected, errs...)
+		pending = failed
+		if len(pending) > 0 {
+			return fmt.Errorf("%d documents rejected temporarily", len(pending))
+		}
+		return nil
+	})
+	var errs []error
+	if err != nil {
+		errs = append(errs, &undeliveredError[esDocument]{
+			items: pending,
+			err:   e.batcher.sendError("elasticsearch", len(pending), "documents", err),
+		})
+	}
+	if len(rejected) > 0 {
+		errs = append(errs, fmt.Errorf("logging: elasticsearch: dropped %d rejected documents: %w", len(rejected), rejected[0]))
+	}
+	return errors.Join(errs...)
+}
+
+// bulk sends the documents and returns the documents
+// rejected with a retryable status and the errors of
+// the documents rejected permanently.
+func (e *esExporter) bulk(ctx context.Context, docs []esDocument) (failed []esDocument, rejected []error, err error) {
+	var body bytes.Buffer
+	for _, doc := range docs {
+		action, err := json.Marshal(map[string]any{
+			"create": map[string]string{"_index": doc.index},
+		})
+		if err != nil {
+			return nil, nil, permanentError{err}
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc.body)
+		body.WriteByte('\n')
+	}
+	req, err := http.NewRequestWithContext(WithoutClientLogging(ctx), http.MethodPost, strings.TrimSuffix(e.config.URL, "/")+"/_bulk", &body)
+	if err != nil {
+		return nil, nil, permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if e.config.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+e.config.APIKey)
+	} else if e.config.Username != "" {
+		req.SetBasicAuth(e.config.Username, e.config.Password)
+	}
+	var resp esBulkResponse
+	if err = doSinkJSONRequest(e.config.Client, req, &resp); err != nil {
+		return nil, nil, err
+	}
+	if !resp.Errors {
+		return nil, nil, nil
+	}
+	for i, item := range resp.Items {
+		if i >= len(docs) {
+			break
+		}
+		for _, result := range item {
+			switch {
+			case result.Status < 300:
+			case retryableStatus(result.Status):
+				failed = append(failed, docs[i])
+			default:
+				rejected = append(rejected, fmt.Errorf("index %s: status %d: %s: %s", docs[i].index, result.Status, result.Error.Type, result.Error.Reason))
+			}
+		}
+	}
+	return failed, rejected, nil
+}
+
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	// Items map the action to its result.
+	Items []map[string]esBulkResult `json:"items"`
+}
+
+type esBulkResult struct {
+	Status int `json:"status"`
+	Error  struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
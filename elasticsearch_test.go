@@ -0,0 +1,193 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type esBulkItem struct {
+	Index string
+	Doc   map[string]any
+}
+
+// esServer stands in for the _bulk API, answering each request
+// with the item statuses returned by respond.
+type esServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests [][]esBulkItem
+	respond  func(request int, items []esBulkItem) []int
+}
+
+func newESServer(t *testing.T, respond func(request int, items []esBulkItem) []int) *esServer {
+	t.Helper()
+	s := &esServer{respond: respond}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_bulk", r.URL.Path)
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "elastic:secret", user+":"+password)
+
+		var items []esBulkItem
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action struct {
+				Create struct {
+					Index string `json:"_index"`
+				} `json:"create"`
+			}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &action))
+			require.True(t, scanner.Scan())
+			item := esBulkItem{Index: action.Create.Index}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &item.Doc))
+			items = append(items, item)
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, items)
+		statuses := s.respond(len(s.requests), items)
+		s.mu.Unlock()
+
+		resp := esBulkResponse{}
+		for _, status := range statuses {
+			resp.Errors = resp.Errors || status >= 300
+			result := esBulkResult{Status: status}
+			if status >= 300 {
+				result.Error.Type = "mapper_parsing_exception"
+				result.Error.Reason = fmt.Sprint("failed with ", status)
+			}
+			resp.Items = append(resp.Items, map[string]esBulkResult{"create": result})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestElasticsearchHandler(t *testing.T, url string, errorHandler func(error)) *ElasticsearchHandler {
+	t.Helper()
+	return NewElasticsearchHandler(ElasticsearchConfig{
+		URL:      url,
+		Index:    "logs-zitadel-{2006.01.02}",
+		Username: "elastic",
+		Password: "secret",
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			ErrorHandler:  errorHandler,
+		},
+		RetryOptions: RetryOptions{
+			Backoff: func(int) time.Duration { return time.Millisecond },
+		},
+	}, nil)
+}
+
+func TestElasticsearchHandler(t *testing.T) {
+	server := newESServer(t, func(int, []esBulkItem) []int { return []int{201} })
+	h := newTestElasticsearchHandler(t, server.URL, nil)
+
+	r := slog.NewRecord(time.Date(2026, 10, 17, 23, 0, 0, 0, time.FixedZone("", -2*3600)), slog.LevelWarn, "hello", 0)
+	r.AddAttrs(slog.Group("http", slog.Int("status", 404)), slog.Duration("duration", time.Second))
+	require.NoError(t, slog.New(h).With("id", "1").Handler().Handle(context.Background(), r))
+	require.NoError(t, h.Close(context.Background()))
+
+	require.Len(t, server.requests, 1)
+	item := server.requests[0][0]
+	assert.Equal(t, "logs-zitadel-2026.10.18", item.Index)
+	got, err := json.Marshal(item.Doc)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"time": "2026-10-17T23:00:00-02:00",
+		"level": "WARN",
+		"msg": "hello",
+		"id": "1",
+		"http": {"status": 404},
+		"duration": 1000000000
+	}`, string(got))
+}
+
+func TestElasticsearchHandler_Write(t *testing.T) {
+	server := newESServer(t, func(_ int, items []esBulkItem) []int { return make([]int, len(items)) })
+	h := newTestElasticsearchHandler(t, server.URL, nil)
+
+	slog.New(slog.NewJSONHandler(h, nil)).Info("from slog", "n", 1)
+	l := logrus.New()
+	l.Out = h
+	l.Formatter = &logrus.JSONFormatter{}
+	l.WithField("n", 2).Info("from logrus")
+	_, err := h.Write([]byte("not json\n"))
+	assert.ErrorContains(t, err, "invalid document")
+	require.NoError(t, h.Close(context.Background()))
+
+	today := "logs-zitadel-" + time.Now().UTC().Format("2006.01.02")
+	require.Len(t, server.requests, 1)
+	items := server.requests[0]
+	require.Len(t, items, 2)
+	assert.Equal(t, today, items[0].Index)
+	assert.Equal(t, "from slog", items[0].Doc["msg"])
+	assert.Equal(t, today, items[1].Index)
+	assert.Equal(t, "from logrus", items[1].Doc["msg"])
+	assert.Equal(t, float64(2), items[1].Doc["n"])
+}
+
+func TestElasticsearchHandler_partialFailure(t *testing.T) {
+	server := newESServer(t, func(request int, items []esBulkItem) []int {
+		if request == 1 {
+			return []int{201, 429, 400}
+		}
+		return []int{201}
+	})
+	var errs []error
+	h := newTestElasticsearchHandler(t, server.URL, func(err error) { errs = append(errs, err) })
+	logger := slog.New(h)
+	for i := range 3 {
+		logger.Info("hello", "n", i)
+	}
+	err := h.Flush(context.Background())
+	assert.ErrorContains(t, err, "dropped 1 rejected documents")
+	assert.ErrorContains(t, err, "status 400: mapper_parsing_exception")
+	require.NoError(t, h.Close(context.Background()))
+
+	require.Len(t, server.requests, 2)
+	assert.Len(t, server.requests[0], 3)
+	require.Len(t, server.requests[1], 1)
+	assert.Equal(t, float64(1), server.requests[1][0].Doc["n"])
+	assert.Empty(t, errs)
+}
+
+func TestElasticsearchHandler_apiKey(t *testing.T) {
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"errors":false}`)
+	}))
+	defer ts.Close()
+	h := NewElasticsearchHandler(ElasticsearchConfig{URL: ts.URL, APIKey: "a2V5"}, nil)
+	slog.New(h).Info("hello")
+	require.NoError(t, h.Close(context.Background()))
+	assert.Equal(t, "ApiKey a2V5", auth)
+}
+
+func Test_esExporter_index(t *testing.T) {
+	ts := time.Date(2026, 10, 17, 1, 0, 0, 0, time.UTC)
+	tests := map[string]string{
+		"logs":                   "logs",
+		"logs-{2006.01.02}":      "logs-2026.10.17",
+		"logs-{2006-01}-zitadel": "logs-2026-10-zitadel",
+	}
+	for index, want := range tests {
+		e := &esExporter{config: ElasticsearchConfig{Index: index}}
+		assert.Equal(t, want, e.index(ts), index)
+	}
+}
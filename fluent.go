@@ -0,0 +1,209 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FluentConfig configures the [FluentHandler].
+type FluentConfig struct {
+	// Network is one of "tcp" (default), "tls" or "unix".
+	Network string `json:"network"`
+	// Address of the forward input, defaults to "localhost:24224".
+	// For "unix", it is the socket path, which is required.
+	Address string `json:"address"`
+	// Tag of the events, defaults to the name of the executable.
+	Tag string `json:"tag"`
+	// RequireAck sends a chunk ID with each batch and waits for
+	// the server to acknowledge it, before the batch is considered sent.
+	RequireAck bool `json:"requireAck" yaml:"requireAck"`
+	// Timeout for connecting, writing and waiting for the ack,
+	// defaults to 10 seconds. Nanoseconds in JSON, like "10s" in YAML.
+	Timeout time.Duration `json:"timeout"`
+	// TLS is used for the "tls" network.
+	TLS *tls.Config `json:"-" yaml:"-"`
+
+	BatchOptions `yaml:",inline"`
+	RetryOptions `yaml:",inline"`
+}
+
+// FluentHandler is a [Sink] sending records to Fluentd or
+// Fluent Bit, using the Forward mode of the forward protocol.
+//
+// Each batch is sent as one MessagePack encoded message,
+// with the record time as EventTime. The record holds the
+// attributes, with groups as nested maps, the "level", the "msg"
+// and, with AddSource, the "source". The built-in keys can be
+// changed with ReplaceAttr.
+//
+// When sending fails, the connection is re-established and the
+// batch is sent again, as configured by the RetryOptions.
+// With RequireAck, the same chunk ID is used for all attempts,
+// so the server can drop duplicates.
+type FluentHandler struct {
+	baseHandler
+	exporter *fluentExporter
+}
+
+// NewFluentHandler creates a [FluentHandler] and starts
+// the background goroutine sending batches.
+// The connection is established with the first batch.
+// It returns an error for an unknown network
+// or a unix network without an address.
+// If opts is nil, the default options are used.
+func NewFluentHandler(config FluentConfig, opts *slog.HandlerOptions) (*FluentHandler, error) {
+	switch config.Network {
+	case "":
+		config.Network = "tcp"
+	case "tcp", "tls", "unix":
+	default:
+		return nil, fmt.Errorf("logging: fluent network %q not supported", config.Network)
+	}
+	if config.Address == "" {
+		if config.Network == "unix" {
+			return nil, errors.New("logging: fluent address is required for the unix network")
+		}
+		config.Address = "localhost:24224"
+	}
+	if config.Tag == "" {
+		config.Tag = filepath.Base(os.Args[0])
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	config.RetryOptions = config.RetryOptions.withDefaults()
+	e := &fluentExporter{
+		config: config,
+		conn:   newSinkConn(config.Network, config.Address, config.TLS, config.Timeout),
+	}
+	e.batcher = newBatcher(config.BatchOptions, e.send, spoolBytes)
+	return &FluentHandler{
+		baseHandler: newBaseHandler(opts),
+		exporter:    e,
+	}, nil
+}
+
+func (h *FluentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &FluentHandler{baseHandler: h.withAttrs(attrs), exporter: h.exporter}
+}
+
+func (h *FluentHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &FluentHandler{baseHandler: h.withGroup(name), exporter: h.exporter}
+}
+
+func (h *FluentHandler) Handle(_ context.Context, r slog.Record) error {
+	record := attrMap(h.recordAttrs(r))
+	if a, ok := h.builtinAttr(slog.Any(slog.LevelKey, r.Level)); ok {
+		record[a.Key] = slog.StringValue(a.Value.String())
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		if a, ok := h.builtinAttr(slog.String(slog.SourceKey, recordSource(r))); ok {
+			record[a.Key] = a.Value
+		}
+	}
+	if a, ok := h.builtinAttr(slog.String(slog.MessageKey, r.Message)); ok {
+		record[a.Key] = a.Value
+	}
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	entry := appendMsgpackArrayHeader(nil, 2)
+	entry = appendMsgpackEventTime(entry, t)
+	entry = appendMsgpackMap(entry, record)
+	return h.exporter.batcher.add(entry)
+}
+
+// Flush sends all buffered records.
+func (h *FluentHandler) Flush(ctx context.Context) error {
+	return h.exporter.batcher.Flush(ctx)
+}
+
+// Close sends all buffered records and closes the connection.
+func (h *FluentHandler) Close(ctx context.Context) error {
+	return errors.Join(
+		h.exporter.batcher.Close(ctx),
+		h.exporter.conn.close(),
+	)
+}
+
+type fluentExporter struct {
+	config  FluentConfig
+	batcher *batcher[[]byte]
+	conn    *sinkConn
+}
+
+// send writes the entries as message in Forward mode:
+// [tag, [[time, record], ...], {"size": n, "chunk": id}]
+func (e *fluentExporter) send(ctx context.Context, entries [][]byte) error {
+	var chunk string
+	if e.config.RequireAck {
+		chunk = newChunkID()
+	}
+	msg := appendMsgpackArrayHeader(nil, 3)
+	msg = appendMsgpackString(msg, e.config.Tag)
+	msg = appendMsgpackArrayHeader(msg, len(entries))
+	for _, entry := range entries {
+		msg = append(msg, entry...)
+	}
+	if chunk == "" {
+		msg = appendMsgpackMapHeader(msg, 1)
+	} else {
+		msg = appendMsgpackMapHeader(msg, 2)
+		msg = appendMsgpackString(msg, "chunk")
+		msg = appendMsgpackString(msg, chunk)
+	}
+	msg = appendMsgpackString(msg, "size")
+	msg = appendMsgpackInt(msg, int64(len(entries)))
+
+	err := e.config.retry(ctx, func() error {
+		err := e.conn.write(ctx, msg)
+		if err == nil && chunk != "" {
+			err = e.readAck(chunk)
+		}
+		if err != nil {
+			e.conn.close()
+		}
+		return err
+	})
+	if err != nil {
+		return e.batcher.sendError("fluent", len(entries), "records", err)
+	}
+	return nil
+}
+
+func (e *fluentExporter) readAck(chunk string) error {
+	conn := e.conn.conn
+	if err := conn.SetReadDeadline(time.Now().Add(e.config.Timeout)); err != nil {
+		return err
+	}
+	resp, err := readMsgpackStringMap(conn)
+	if err != nil {
+		return fmt.Errorf("reading ack: %w", err)
+	}
+	if resp["ack"] != chunk {
+		return fmt.Errorf("ack %q does not match chunk %q", resp["ack"], chunk)
+	}
+	return nil
+}
+
+// newChunkID returns a random, base64 encoded chunk ID.
+func newChunkID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return base64.StdEncoding.EncodeToString(b[:])
+}
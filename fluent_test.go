@@ -0,0 +1,197 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fluentServer stands in for a forward input,
+// acknowledging chunks if ack is set.
+type fluentServer struct {
+	listener net.Listener
+	messages chan []any
+	ack      atomic.Bool
+}
+
+func newFluentServer(t *testing.T, ack bool) *fluentServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	s := &fluentServer{listener: listener, messages: make(chan []any, 10)}
+	s.ack.Store(ack)
+	go s.serve()
+	return s
+}
+
+func (s *fluentServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fluentServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		msg, err := decodeMsgpack(r)
+		if err != nil {
+			return
+		}
+		s.messages <- msg.([]any)
+		if !s.ack.Load() {
+			continue
+		}
+		resp := appendMsgpackMapHeader(nil, 1)
+		resp = appendMsgpackString(resp, "ack")
+		resp = appendMsgpackString(resp, msg.([]any)[2].(map[string]any)["chunk"].(string))
+		if _, err = conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fluentServer) next(t *testing.T) []any {
+	t.Helper()
+	select {
+	case msg := <-s.messages:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+func TestFluentHandler(t *testing.T) {
+	for _, ack := range []bool{false, true} {
+		t.Run(map[bool]string{false: "without ack", true: "with ack"}[ack], func(t *testing.T) {
+			server := newFluentServer(t, ack)
+			h, err := NewFluentHandler(FluentConfig{
+				Address:      server.listener.Addr().String(),
+				Tag:          "app.access",
+				RequireAck:   ack,
+				BatchOptions: BatchOptions{FlushInterval: time.Hour},
+			}, nil)
+			require.NoError(t, err)
+
+			ts := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+			logger := slog.New(h).With("id", 1)
+			for _, msg := range []string{"first", "second"} {
+				r := slog.NewRecord(ts, slog.LevelWarn, msg, 0)
+				r.AddAttrs(slog.Group("http", slog.String("path", "/"), slog.Bool("slow", true)))
+				require.NoError(t, logger.Handler().Handle(context.Background(), r))
+			}
+			require.NoError(t, h.Close(context.Background()))
+
+			msg := server.next(t)
+			assert.Equal(t, "app.access", msg[0])
+			record := map[string]any{
+				"id":    int64(1),
+				"level": "WARN",
+				"http":  map[string]any{"path": "/", "slow": true},
+			}
+			first := map[string]any{"msg": "first"}
+			second := map[string]any{"msg": "second"}
+			for k, v := range record {
+				first[k], second[k] = v, v
+			}
+			assert.Equal(t, []any{
+				[]any{ts, first},
+				[]any{ts, second},
+			}, msg[1])
+			option := msg[2].(map[string]any)
+			assert.Equal(t, int64(2), option["size"])
+			if ack {
+				assert.NotEmpty(t, option["chunk"])
+			} else {
+				assert.NotContains(t, option, "chunk")
+			}
+		})
+	}
+}
+
+func TestFluentHandler_reconnect(t *testing.T) {
+	server := newFluentServer(t, true)
+	var errs []error
+	h, err := NewFluentHandler(FluentConfig{
+		Address:    server.listener.Addr().String(),
+		RequireAck: true,
+		Timeout:    100 * time.Millisecond,
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			ErrorHandler:  func(err error) { errs = append(errs, err) },
+		},
+		RetryOptions: RetryOptions{
+			MaxAttempts: 3,
+			Backoff:     func(int) time.Duration { return time.Millisecond },
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	slog.New(h).Info("first")
+	require.NoError(t, h.Flush(context.Background()))
+	server.next(t)
+
+	// the server stops acknowledging,
+	// so the batch is sent again on a new connection.
+	server.ack.Store(false)
+	slog.New(h).Info("second")
+	err = h.Flush(context.Background())
+	assert.ErrorContains(t, err, "dropped 1 records")
+	assert.ErrorContains(t, err, "reading ack")
+	var chunks []any
+	for range 3 {
+		msg := server.next(t)
+		chunks = append(chunks, msg[2].(map[string]any)["chunk"])
+	}
+	assert.Equal(t, chunks[0], chunks[1])
+	assert.Equal(t, chunks[0], chunks[2])
+	require.NoError(t, h.Close(context.Background()))
+	assert.Empty(t, errs)
+}
+
+func TestFluentHandler_spool(t *testing.T) {
+	server := newFluentServer(t, false)
+	h, err := NewFluentHandler(FluentConfig{
+		Address:    server.listener.Addr().String(),
+		RequireAck: true,
+		Timeout:    100 * time.Millisecond,
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			Spool:         SpoolOptions{Dir: t.TempDir()},
+		},
+		RetryOptions: RetryOptions{MaxAttempts: 1},
+	}, nil)
+	require.NoError(t, err)
+
+	slog.New(h).Info("spooled")
+	err = h.Flush(context.Background())
+	assert.ErrorContains(t, err, "spooling records until the destination is available: logging: fluent: sending 1 records")
+	assert.NotContains(t, err.Error(), "dropped")
+	server.next(t)
+
+	// the spooled record is sent again once the server acknowledges.
+	server.ack.Store(true)
+	require.NoError(t, h.Close(context.Background()))
+	msg := server.next(t)
+	assert.Equal(t, "spooled", msg[1].([]any)[0].([]any)[1].(map[string]any)["msg"])
+}
+
+func TestNewFluentHandler_error(t *testing.T) {
+	_, err := NewFluentHandler(FluentConfig{Network: "udp"}, nil)
+	assert.Error(t, err)
+	_, err = NewFluentHandler(FluentConfig{Network: "unix"}, nil)
+	assert.EqualError(t, err, "logging: fluent address is required for the unix network")
+}
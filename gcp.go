@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GCPHandlerOptions are options for [NewGCPHandler].
+type GCPHandlerOptions struct {
+	slog.HandlerOptions
+
+	// ProjectID is used to build the trace resource name
+	// from a "trace_id" attribute.
+	// If empty, the trace ID is used as-is.
+	ProjectID string
+}
+
+// NewGCPHandler creates a [slog.Handler] which writes JSON in the
+// Google Cloud Logging structured logging schema.
+//
+//   - The level is written as "severity".
+//   - The message is written as "message".
+//   - The source is written as "logging.googleapis.com/sourceLocation".
+//   - "trace_id" and "span_id" attributes are written as
+//     "logging.googleapis.com/trace" and "logging.googleapis.com/spanId".
+//   - The "request", "response" and "duration" attributes produced by
+//     [Middleware] and [EnableHTTPClient] are written as "httpRequest".
+//
+// ReplaceAttr is not called for the built-in attributes.
+// If opts is nil, the default options are used.
+func NewGCPHandler(w io.Writer, opts *GCPHandlerOptions) slog.Handler {
+	if opts == nil {
+		opts = new(GCPHandlerOptions)
+	}
+	return newJSONProfileHandler(w, &opts.HandlerOptions, gcpProfile(opts.ProjectID))
+}
+
+// GCPFormatter formats logrus entries like [NewGCPHandler].
+type GCPFormatter struct {
+	// ProjectID is used to build the trace resource name
+	// from a "trace_id" field.
+	ProjectID string
+}
+
+// Format implements [logrus.Formatter].
+func (f *GCPFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	return formatJSONProfile(entry, gcpProfile(f.ProjectID))
+}
+
+const (
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+)
+
+func gcpProfile(projectID string) jsonProfile {
+	return func(r *jsonRecord) map[string]any {
+		m := r.attrs
+		m["severity"] = gcpSeverity(r.level)
+		m["message"] = r.message
+		if !r.time.IsZero() {
+			m["time"] = r.time.Format(time.RFC3339Nano)
+		}
+		if r.source != nil {
+			m[gcpSourceLocationKey] = map[string]any{
+				"file":     r.source.File,
+				"line":     strconv.Itoa(r.source.Line),
+				"function": r.source.Function,
+			}
+		}
+		if traceID, ok := popValue(m, "trace_id"); ok {
+			trace := traceID.String()
+			if projectID != "" {
+				trace = "projects/" + projectID + "/traces/" + trace
+			}
+			m[gcpTraceKey] = trace
+		}
+		if spanID, ok := popValue(m, "span_id"); ok {
+			m[gcpSpanIDKey] = spanID.String()
+		}
+		if httpRequest := gcpHTTPRequest(r); len(httpRequest) > 0 {
+			m["httpRequest"] = httpRequest
+		}
+		return m
+	}
+}
+
+// gcpHTTPRequest moves the attributes of [Middleware]
+// and [EnableHTTPClient] into a HttpRequest object.
+func gcpHTTPRequest(r *jsonRecord) map[string]any {
+	if r.http == nil {
+		return nil
+	}
+	m := r.attrs
+	httpRequest := make(map[string]any)
+	if method, ok := popValue(m, r.httpPath("request", "method")...); ok {
+		httpRequest["requestMethod"] = method.String()
+	}
+	if url, ok := popValue(m, r.httpPath("request", "url")...); ok {
+		httpRequest["requestUrl"] = url.String()
+	}
+	if status, ok := popValue(m, r.httpPath("response", "status")...); ok {
+		httpRequest["status"] = statusCode(status)
+	}
+	for _, key := range []string{"written", "content_length"} {
+		if size, ok := popValue(m, r.httpPath("response", key)...); ok {
+			httpRequest["responseSize"] = size.String()
+		}
+	}
+	if duration, ok := popValue(m, r.httpPath("duration")...); ok && duration.Kind() == slog.KindDuration {
+		httpRequest["latency"] = strconv.FormatFloat(duration.Duration().Seconds(), 'f', -1, 64) + "s"
+	}
+	return httpRequest
+}
+
+// statusCode returns the status code of a "200" or "200 OK" value.
+func statusCode(v slog.Value) int64 {
+	if v.Kind() == slog.KindInt64 {
+		return v.Int64()
+	}
+	code, _, _ := strings.Cut(v.String(), " ")
+	n, _ := strconv.ParseInt(code, 10, 64)
+	return n
+}
+
+func gcpSeverity(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "DEBUG"
+	case level < slog.LevelWarn:
+		return "INFO"
+	case level < slog.LevelError:
+		return "WARNING"
+	case level < slog.LevelError+4:
+		return "ERROR"
+	case level < slog.LevelError+8:
+		return "CRITICAL"
+	default:
+		return "ALERT"
+	}
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCPHandler_middleware(t *testing.T) {
+	out := new(strings.Builder)
+	logger := slog.New(NewGCPHandler(out, &GCPHandlerOptions{ProjectID: "my-project"}))
+	mw := Middleware(
+		WithLogger(logger.With("trace_id", "abc", "span_id", "def")),
+		WithIDFunc(func() slog.Attr {
+			return slog.String("id", "id1")
+		}),
+		WithDurationFunc(func(time.Time) time.Duration {
+			return 1500 * time.Millisecond
+		}),
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Hello, World!")
+	})
+	mw(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "https://example.com/path/", nil))
+
+	got := out.String()
+	assert.Regexp(t, `"time":"\d{4}-\d\d-\d\dT`, got)
+	assert.JSONEq(t, `{
+		"severity":"INFO",
+		"message":"request served",
+		"time":"ignored",
+		"id":"id1",
+		"logging.googleapis.com/trace":"projects/my-project/traces/abc",
+		"logging.googleapis.com/spanId":"def",
+		"httpRequest":{
+			"requestMethod":"GET",
+			"requestUrl":"https://example.com/path/",
+			"status":200,
+			"responseSize":"13",
+			"latency":"1.5s"
+		}
+	}`, replaceJSONField(t, got, "time", "ignored"))
+}
+
+func TestGCPHandler_client(t *testing.T) {
+	out := new(strings.Builder)
+	logger := slog.New(NewGCPHandler(out, &GCPHandlerOptions{
+		HandlerOptions: slog.HandlerOptions{AddSource: true},
+	}))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+	c := new(http.Client)
+	EnableHTTPClient(c,
+		WithFallbackLogger(logger),
+		WithClientStatusLevel(StatusLevel),
+		WithClientDurationFunc(func(time.Time) time.Duration {
+			return time.Second
+		}),
+	)
+	_, err := c.Get(ts.URL)
+	require.NoError(t, err)
+
+	got := out.String()
+	assert.Contains(t, got, `"logging.googleapis.com/sourceLocation":{"file":`)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"severity":"WARNING",
+		"message":"request roundtrip",
+		"time":"ignored",
+		"logging.googleapis.com/sourceLocation":"ignored",
+		"httpRequest":{
+			"requestMethod":"GET",
+			"requestUrl":"%s",
+			"status":404,
+			"responseSize":"19",
+			"latency":"1s"
+		}
+	}`, ts.URL), replaceJSONField(t, replaceJSONField(t, got, "time", "ignored"), gcpSourceLocationKey, "ignored"))
+}
+
+func TestGCPFormatter(t *testing.T) {
+	logger := logrus.New()
+	out := new(bytes.Buffer)
+	logger.SetOutput(out)
+	logger.SetFormatter(&GCPFormatter{ProjectID: "my-project"})
+	logger.WithFields(logrus.Fields{
+		"caller":   "/src/main.go:12",
+		"trace_id": "abc",
+		"user":     "hodor",
+	}).WithTime(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)).Error("oops")
+
+	assert.JSONEq(t, `{
+		"severity":"ERROR",
+		"message":"oops",
+		"time":"2026-10-17T12:00:00Z",
+		"user":"hodor",
+		"logging.googleapis.com/trace":"projects/my-project/traces/abc",
+		"logging.googleapis.com/sourceLocation":{"file":"/src/main.go","line":"12","function":""}
+	}`, out.String())
+}
+
+func Test_gcpSeverity(t *testing.T) {
+	assert.Equal(t, "DEBUG", gcpSeverity(slog.LevelDebug))
+	assert.Equal(t, "INFO", gcpSeverity(slog.LevelInfo))
+	assert.Equal(t, "WARNING", gcpSeverity(slog.LevelWarn))
+	assert.Equal(t, "ERROR", gcpSeverity(slog.LevelError))
+	assert.Equal(t, "CRITICAL", gcpSeverity(slogLevel(logrus.FatalLevel)))
+	assert.Equal(t, "ALERT", gcpSeverity(slogLevel(logrus.PanicLevel)))
+}
+
+func TestGCPHandler_grpc(t *testing.T) {
+	out := new(strings.Builder)
+	logger := slog.New(NewGCPHandler(out, nil))
+	// the attributes logged by the gRPC interceptors.
+	logger.Info("request served",
+		slog.Group("request", "method", "/test.Service/Get", "peer", "bufconn"),
+		slog.Group("response", "code", "OK"),
+	)
+	assert.JSONEq(t, `{
+		"severity":"INFO",
+		"message":"request served",
+		"time":"ignored",
+		"request":{"method":"/test.Service/Get","peer":"bufconn"},
+		"response":{"code":"OK"}
+	}`, replaceJSONField(t, out.String(), "time", "ignored"))
+}
@@ -0,0 +1,304 @@
+package logging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"os"
+	"time"
+)
+
+// GELFConfig configures the [GELFHandler].
+type GELFConfig struct {
+	// Network is one of "udp" (default), "tcp" or "tls".
+	Network string `json:"network"`
+	// Address of the Graylog input, defaults to "localhost:12201".
+	Address string `json:"address"`
+	// Host is sent as the host field, defaults to [os.Hostname].
+	Host string `json:"host"`
+	// Compression of UDP messages, one of "gzip" (default),
+	// "zlib" or "none". TCP messages are never compressed.
+	Compression string `json:"compression"`
+	// ChunkSize is the maximum size of a UDP datagram,
+	// defaults to 1420. Larger messages are chunked.
+	ChunkSize int `json:"chunkSize" yaml:"chunkSize"`
+	// Timeout for connecting and writing, defaults to 10 seconds,
+	// as nanoseconds in JSON or a duration like "10s" in YAML.
+	Timeout time.Duration `json:"timeout"`
+	// TLS is used for the "tls" network.
+	TLS *tls.Config `json:"-" yaml:"-"`
+
+	BatchOptions `yaml:",inline"`
+}
+
+// GELFHandler is a [Sink] sending records to Graylog
+// in the Graylog Extended Log Format 1.1.
+//
+// Over UDP, messages are compressed and split into chunks
+// when exceeding the ChunkSize. Over TCP, messages are
+// terminated by a null byte.
+//
+// The level is sent as syslog severity, see [SyslogHandler].
+// Attributes are sent as additional fields prefixed with an
+// underscore, with groups joined by an underscore.
+// Numbers are sent as numbers, all other values as strings.
+// With AddSource, or from the "caller" field set for logrus,
+// the source is sent as _file and _line.
+type GELFHandler struct {
+	baseHandler
+	exporter *gelfExporter
+}
+
+// NewGELFHandler creates a [GELFHandler] and starts
+// the background goroutine sending messages.
+// The connection is established with the first message.
+// It returns an error for an unknown network or compression.
+// If opts is nil, the default options are used.
+func NewGELFHandler(config GELFConfig, opts *slog.HandlerOptions) (*GELFHandler, error) {
+	switch config.Network {
+	case "":
+		config.Network = "udp"
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("logging: gelf network %q not supported", config.Network)
+	}
+	switch config.Compression {
+	case "":
+		config.Compression = "gzip"
+	case "gzip", "zlib", "none":
+	default:
+		return nil, fmt.Errorf("logging: gelf compression %q not supported", config.Compression)
+	}
+	if config.Address == "" {
+		config.Address = "localhost:12201"
+	}
+	if config.Host == "" {
+		config.Host, _ = os.Hostname()
+	}
+	if config.ChunkSize <= gelfChunkHeaderSize {
+		config.ChunkSize = 1420
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	e := &gelfExporter{
+		config: config,
+		conn:   newSinkConn(config.Network, config.Address, config.TLS, config.Timeout),
+	}
+	e.batcher = newBatcher(config.BatchOptions, e.send, spoolBytes)
+	return &GELFHandler{
+		baseHandler: newBaseHandler(opts),
+		exporter:    e,
+	}, nil
+}
+
+func (h *GELFHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &GELFHandler{baseHandler: h.withAttrs(attrs), exporter: h.exporter}
+}
+
+func (h *GELFHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &GELFHandler{baseHandler: h.withGroup(name), exporter: h.exporter}
+}
+
+func (h *GELFHandler) Handle(_ context.Context, r slog.Record) error {
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	m := map[string]any{
+		"version":       "1.1",
+		"host":          h.exporter.config.Host,
+		"short_message": r.Message,
+		"timestamp":     float64(t.UnixMicro()) / 1e6,
+		"level":         syslogSeverity(r.Level),
+	}
+	var source *slog.Source
+	if h.opts.AddSource && r.PC != 0 {
+		source, _ = parseCaller(slog.StringValue(recordSource(r)))
+	}
+	for _, a := range h.recordAttrs(r) {
+		if source == nil && len(a.groups) == 0 && a.attr.Key == "caller" {
+			if caller, ok := parseCaller(a.attr.Value); ok {
+				source = caller
+				continue
+			}
+		}
+		m[gelfFieldName(a.key("_"))] = gelfValue(a.attr.Value)
+	}
+	if source != nil {
+		m["_file"] = source.File
+		m["_line"] = source.Line
+	}
+	msg, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return h.exporter.batcher.add(msg)
+}
+
+// Flush sends all buffered messages.
+func (h *GELFHandler) Flush(ctx context.Context) error {
+	return h.exporter.batcher.Flush(ctx)
+}
+
+// Close sends all buffered messages and closes the connection.
+func (h *GELFHandler) Close(ctx context.Context) error {
+	return errors.Join(
+		h.exporter.batcher.Close(ctx),
+		h.exporter.conn.close(),
+	)
+}
+
+type gelfExporter struct {
+	config  GELFConfig
+	batcher *batcher[[]byte]
+	conn    *sinkConn
+}
+
+func (e *gelfExporter) send(ctx context.Context, msgs [][]byte) error {
+	var (
+		errs   []error
+		failed [][]byte
+	)
+	for _, msg := range msgs {
+		if err := e.sendMessage(ctx, msg); err != nil {
+			errs = append(errs, err)
+			failed = append(failed, msg)
+		}
+	}
+	if len(errs) > 0 {
+		return &undeliveredError[[]byte]{
+			items: failed,
+			err:   e.batcher.sendError("gelf", len(errs), "messages", errors.Join(errs...)),
+		}
+	}
+	return nil
+}
+
+func (e *gelfExporter) sendMessage(ctx context.Context, msg []byte) error {
+	if e.config.Network != "udp" {
+		return e.conn.write(ctx, append(msg, 0))
+	}
+	data, err := e.compress(msg)
+	if err != nil {
+		return err
+	}
+	if len(data) <= e.config.ChunkSize {
+		return e.conn.write(ctx, data)
+	}
+	chunks, err := gelfChunks(data, e.config.ChunkSize, rand.Uint64())
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if err = e.conn.write(ctx, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *gelfExporter) compress(msg []byte) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch e.config.Compression {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	default:
+		return msg, nil
+	}
+	if _, err := w.Write(msg); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const (
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+)
+
+// gelfChunks splits data into chunks of at most size bytes, each
+// starting with the magic bytes 0x1e 0x0f, the message id,
+// the sequence number and the sequence count.
+func gelfChunks(data []byte, size int, id uint64) ([][]byte, error) {
+	payload := size - gelfChunkHeaderSize
+	count := (len(data) + payload - 1) / payload
+	if count > gelfMaxChunks {
+		return nil, fmt.Errorf("message of %d bytes exceeds %d chunks", len(data), gelfMaxChunks)
+	}
+	chunks := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		part := data[seq*payload : min((seq+1)*payload, len(data))]
+		chunk := make([]byte, 0, gelfChunkHeaderSize+len(part))
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = binary.BigEndian.AppendUint64(chunk, id)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunks = append(chunks, append(chunk, part...))
+	}
+	return chunks, nil
+}
+
+// gelfFieldName returns the name of an additional field,
+// which is prefixed with an underscore and may only contain
+// letters, digits, underscores, dashes and dots.
+// The reserved "_id" is sent as "__id".
+func gelfFieldName(key string) string {
+	b := make([]byte, 0, len(key)+1)
+	b = append(b, '_')
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			c == '_', c == '-', c == '.':
+		default:
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	if string(b) == "_id" {
+		return "__id"
+	}
+	return string(b)
+}
+
+// gelfValue returns finite numbers as they are and
+// all other values as strings.
+func gelfValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		if f := v.Float64(); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return f
+		}
+		return syslogValue(v)
+	default:
+		return syslogValue(v)
+	}
+}
@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGELFHandler(t *testing.T, config GELFConfig) *GELFHandler {
+	t.Helper()
+	config.Host = "host"
+	config.FlushInterval = time.Hour
+	h, err := NewGELFHandler(config, nil)
+	require.NoError(t, err)
+	return h
+}
+
+func handleGELFTestRecord(t *testing.T, h *GELFHandler, message string) {
+	t.Helper()
+	r := slog.NewRecord(time.Unix(1715000000, 123000000), slog.LevelWarn, message, 0)
+	r.AddAttrs(
+		slog.Int("id", 1),
+		slog.Group("http", slog.String("path", "/"), slog.Float64("ratio", 0.5)),
+		slog.Bool("slow", true),
+		slog.String("caller", "/src/main.go:42"),
+	)
+	require.NoError(t, h.Handle(context.Background(), r))
+	require.NoError(t, h.Close(context.Background()))
+}
+
+const gelfTestMessage = `{
+	"version": "1.1",
+	"host": "host",
+	"short_message": "hello",
+	"timestamp": 1715000000.123,
+	"level": 4,
+	"__id": 1,
+	"_http_path": "/",
+	"_http_ratio": 0.5,
+	"_slow": "true",
+	"_file": "/src/main.go",
+	"_line": 42
+}`
+
+func TestGELFHandler_udp(t *testing.T) {
+	tests := []struct {
+		compression string
+		decompress  func(io.Reader) (io.Reader, error)
+	}{
+		{"gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"zlib", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+		{"none", func(r io.Reader) (io.Reader, error) { return r, nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.compression, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer conn.Close()
+
+			handleGELFTestRecord(t, newTestGELFHandler(t, GELFConfig{
+				Address:     conn.LocalAddr().String(),
+				Compression: tt.compression,
+			}), "hello")
+			r, err := tt.decompress(strings.NewReader(readDatagrams(t, conn, 1)[0]))
+			require.NoError(t, err)
+			got, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.JSONEq(t, gelfTestMessage, string(got))
+		})
+	}
+}
+
+func TestGELFHandler_chunked(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	message := strings.Repeat("hello", 100)
+	handleGELFTestRecord(t, newTestGELFHandler(t, GELFConfig{
+		Address:     conn.LocalAddr().String(),
+		Compression: "none",
+		ChunkSize:   100,
+	}), message)
+
+	chunks := readDatagrams(t, conn, 8)
+	var data []byte
+	for seq, chunk := range chunks {
+		assert.Equal(t, "\x1e\x0f", chunk[:2])
+		assert.Equal(t, chunks[0][2:10], chunk[2:10], "message id")
+		assert.Equal(t, byte(seq), chunk[10])
+		assert.Equal(t, byte(8), chunk[11])
+		assert.LessOrEqual(t, len(chunk), 100)
+		data = append(data, chunk[12:]...)
+	}
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, message, got["short_message"])
+}
+
+func TestGELFHandler_tcp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	messages := make(chan []string, 1)
+	go func() {
+		var msgs []string
+		defer func() { messages <- msgs }()
+		conn, err := listener.Accept()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := r.ReadBytes(0)
+			if err != nil {
+				return
+			}
+			msgs = append(msgs, string(bytes.TrimSuffix(msg, []byte{0})))
+		}
+	}()
+
+	h := newTestGELFHandler(t, GELFConfig{
+		Network: "tcp",
+		Address: listener.Addr().String(),
+	})
+	slog.New(h).Info("first")
+	handleGELFTestRecord(t, h, "hello")
+	got := <-messages
+	require.Len(t, got, 2)
+	assert.JSONEq(t, gelfTestMessage, got[1])
+}
+
+func TestNewGELFHandler_errors(t *testing.T) {
+	for _, config := range []GELFConfig{
+		{Network: "unix"},
+		{Compression: "brotli"},
+	} {
+		_, err := NewGELFHandler(config, nil)
+		assert.Error(t, err)
+	}
+}
+
+func Test_gelfChunks_tooLarge(t *testing.T) {
+	_, err := gelfChunks(make([]byte, 129*88), 100, 1)
+	assert.ErrorContains(t, err, "exceeds 128 chunks")
+	chunks, err := gelfChunks(make([]byte, 128*88), 100, 1)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 128)
+}
+
+func Test_gelfFieldName(t *testing.T) {
+	tests := map[string]string{
+		"id":        "__id",
+		"http_path": "_http_path",
+		"trace-id":  "_trace-id",
+		"a.b":       "_a.b",
+		"a b/c":     "_a_b_c",
+	}
+	for key, want := range tests {
+		assert.Equal(t, want, gelfFieldName(key), key)
+	}
+}
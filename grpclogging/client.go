@@ -0,0 +1,175 @@
+package grpclogging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/zitadel/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ClientOption func(*grpcClient)
+
+// WithClientFallbackLogger uses the passed logger if none was
+// found in the context.
+func WithClientFallbackLogger(logger *slog.Logger) ClientOption {
+	return func(c *grpcClient) {
+		c.fallback = logger
+	}
+}
+
+// WithClientGroup groups the log attributes
+// produced by the client interceptors.
+func WithClientGroup(name string) ClientOption {
+	return func(c *grpcClient) {
+		c.group = name
+	}
+}
+
+// WithClientDurationFunc allows overriding the call duration
+// for testing.
+func WithClientDurationFunc(df func(time.Time) time.Duration) ClientOption {
+	return func(c *grpcClient) {
+		c.duration = df
+	}
+}
+
+// WithClientCodeLevel allows customizing the level
+// of the log line based on the returned status code.
+// The default is [CodeLevel].
+func WithClientCodeLevel(codeLevel func(codes.Code) slog.Level) ClientOption {
+	return func(c *grpcClient) {
+		c.codeLevel = codeLevel
+	}
+}
+
+// UnaryClientInterceptor adds slog functionality to a gRPC client,
+// like [logging.EnableHTTPClient] does for HTTP.
+// It attempts to obtain a logger with [logging.FromContext].
+// If no logger is in the context, it tries to use a fallback logger,
+// which might be set by [WithClientFallbackLogger].
+// If no logger was found finally, or the context was
+// passed to [logging.WithoutClientLogging], the call is
+// executed without logging.
+func UnaryClientInterceptor(opts ...ClientOption) grpc.UnaryClientInterceptor {
+	c := newGRPCClient(opts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		logger, ok := c.fromContextOrFallback(ctx)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		c.log(ctx, logger, method, cc.Target(), start, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor is like [UnaryClientInterceptor] for streams.
+// The line is printed once the stream ends,
+// which is when RecvMsg returns an error or [io.EOF].
+// For client-streaming calls, which receive a single response,
+// it is also printed when RecvMsg returns the response.
+// Streams which are not received until the end are not logged.
+func StreamClientInterceptor(opts ...ClientOption) grpc.StreamClientInterceptor {
+	c := newGRPCClient(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		logger, ok := c.fromContextOrFallback(ctx)
+		if !ok {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			c.log(ctx, logger, method, cc.Target(), start, err)
+			return cs, err
+		}
+		return &loggedClientStream{
+			ClientStream:  cs,
+			serverStreams: desc.ServerStreams,
+			done: func(err error) {
+				c.log(ctx, logger, method, cc.Target(), start, err)
+			},
+		}, nil
+	}
+}
+
+func newGRPCClient(opts []ClientOption) *grpcClient {
+	c := &grpcClient{
+		duration:  time.Since,
+		codeLevel: CodeLevel,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type grpcClient struct {
+	fallback  *slog.Logger
+	group     string
+	duration  func(time.Time) time.Duration
+	codeLevel func(codes.Code) slog.Level
+}
+
+func (c *grpcClient) fromContextOrFallback(ctx context.Context) (*slog.Logger, bool) {
+	if logging.ClientLoggingDisabled(ctx) {
+		return nil, false
+	}
+	if logger, ok := logging.FromContext(ctx); ok {
+		return logger, ok
+	}
+	return c.fallback, c.fallback != nil
+}
+
+func (c *grpcClient) log(ctx context.Context, logger *slog.Logger, method, target string, start time.Time, err error) {
+	code := status.Code(err)
+	attrs := []any{
+		slog.Group("request",
+			slog.String("method", method),
+			slog.String("target", target),
+		),
+		slog.Duration("duration", c.duration(start)),
+		slog.Group("response", slog.String("code", code.String())),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	logger.WithGroup(c.group).Log(ctx, c.codeLevel(code), "request roundtrip", attrs...)
+}
+
+// loggedClientStream calls done once,
+// when the stream ended.
+type loggedClientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+
+	once sync.Once
+	done func(err error)
+}
+
+func (s *loggedClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil && !s.serverStreams {
+		// the single response already carries the status of the call.
+		s.once.Do(func() {
+			s.done(nil)
+		})
+	}
+	if err != nil {
+		s.once.Do(func() {
+			if errors.Is(err, io.EOF) {
+				s.done(nil)
+				return
+			}
+			s.done(err)
+		})
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package grpclogging
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"log/slog"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zitadel/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestGRPCClientInterceptors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		fromCtx bool
+		want    string
+		wantErr codes.Code
+	}{
+		{
+			name: "ok",
+			want: `{
+				"level":"INFO",
+				"time":"not",
+				"msg":"request roundtrip",
+				"request":{"method":"%s","target":"passthrough:///bufnet"},
+				"duration":1000000000,
+				"response":{"code":"OK"}
+			}`,
+		},
+		{
+			name:    "error",
+			err:     errors.New("oops"),
+			wantErr: codes.Unknown,
+			want: `{
+				"level":"ERROR",
+				"time":"not",
+				"msg":"request roundtrip",
+				"request":{"method":"%s","target":"passthrough:///bufnet"},
+				"duration":1000000000,
+				"response":{"code":"Unknown"},
+				"error":"rpc error: code = Unknown desc = oops"
+			}`,
+		},
+		{
+			name:    "logger from ctx",
+			fromCtx: true,
+			want: `{
+				"level":"INFO",
+				"time":"not",
+				"msg":"request roundtrip",
+				"ctx":{
+					"request":{"method":"%s","target":"passthrough:///bufnet"},
+					"duration":1000000000,
+					"response":{"code":"OK"}
+				}
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, logger := newTestLogger()
+			opts := []ClientOption{
+				WithClientFallbackLogger(logger),
+				WithClientDurationFunc(func(time.Time) time.Duration {
+					return time.Second
+				}),
+			}
+			serverLogger := WithLogger(slog.New(slog.DiscardHandler))
+			conn := newTestGRPCConn(t, &testGRPCService{err: tt.err},
+				[]grpc.ServerOption{
+					grpc.UnaryInterceptor(UnaryServerInterceptor(serverLogger)),
+					grpc.StreamInterceptor(StreamServerInterceptor(serverLogger)),
+				},
+				grpc.WithUnaryInterceptor(UnaryClientInterceptor(opts...)),
+				grpc.WithStreamInterceptor(StreamClientInterceptor(opts...)),
+			)
+			ctx := t.Context()
+			if tt.fromCtx {
+				ctx = logging.ToContext(ctx, logger.WithGroup("ctx"))
+			}
+
+			t.Run("unary", func(t *testing.T) {
+				out.Reset()
+				err := conn.Invoke(ctx, "/test.Test/Unary", new(emptypb.Empty), new(emptypb.Empty))
+				assert.Equal(t, tt.wantErr, status.Code(err))
+				assert.JSONEq(t, fmt.Sprintf(tt.want, "/test.Test/Unary"), out.String())
+			})
+			t.Run("stream", func(t *testing.T) {
+				out.Reset()
+				err := callTestGRPCStream(ctx, conn)
+				assert.Equal(t, tt.wantErr, status.Code(err))
+				assert.JSONEq(t, fmt.Sprintf(tt.want, "/test.Test/Stream"), out.String())
+			})
+			t.Run("client stream", func(t *testing.T) {
+				out.Reset()
+				err := callTestGRPCClientStream(ctx, conn)
+				assert.Equal(t, tt.wantErr, status.Code(err))
+				assert.JSONEq(t, fmt.Sprintf(tt.want, "/test.Test/ClientStream"), out.String())
+			})
+			t.Run("without logging", func(t *testing.T) {
+				out.Reset()
+				err := conn.Invoke(logging.WithoutClientLogging(ctx), "/test.Test/Unary", new(emptypb.Empty), new(emptypb.Empty))
+				assert.Equal(t, tt.wantErr, status.Code(err))
+				assert.NotContains(t, out.String(), "request roundtrip")
+			})
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Package grpclogging provides gRPC interceptors which log calls
+// and set a logger to the call's context, like the HTTP middleware
+// and client of the logging package.
+// It is a separate module, so the logging package
+// does not depend on gRPC.
+package grpclogging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/zitadel/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type ServerOption func(*grpcServer)
+
+// WithLogger sets the passed logger with request attributes
+// into the call's context.
+func WithLogger(logger *slog.Logger) ServerOption {
+	return func(s *grpcServer) {
+		s.logger = logger
+	}
+}
+
+// WithGroup groups the log attributes
+// produced by the interceptors.
+func WithGroup(name string) ServerOption {
+	return func(s *grpcServer) {
+		s.group = name
+	}
+}
+
+// WithIDFunc enables the creating of request IDs
+// in the interceptors, which are then attached to
+// the logger.
+func WithIDFunc(nextID func() slog.Attr) ServerOption {
+	return func(s *grpcServer) {
+		s.nextID = nextID
+	}
+}
+
+// WithDurationFunc allows overriding the call duration for testing.
+func WithDurationFunc(df func(time.Time) time.Duration) ServerOption {
+	return func(s *grpcServer) {
+		s.duration = df
+	}
+}
+
+// WithCodeLevel allows customizing the level
+// of the log line based on the returned status code.
+// The default is [CodeLevel].
+func WithCodeLevel(codeLevel func(codes.Code) slog.Level) ServerOption {
+	return func(s *grpcServer) {
+		s.codeLevel = codeLevel
+	}
+}
+
+// CodeLevel maps status codes caused by the server to ERROR,
+// status codes caused by the client to WARN and OK to INFO.
+func CodeLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Unknown,
+		codes.DeadlineExceeded,
+		codes.Unimplemented,
+		codes.Internal,
+		codes.Unavailable,
+		codes.DataLoss:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// UnaryServerInterceptor enables call logging and sets a logger
+// to the call's context, like [logging.Middleware] does for HTTP.
+// Use [logging.FromContext] to obtain the logger anywhere in the call's lifetime.
+//
+// The default logger is [slog.Default], with the full method and
+// peer address as preset attributes.
+// When the call returns, a line with the status code is printed,
+// at a level defined by [CodeLevel].
+// This behaviors can be modified with options.
+func UnaryServerInterceptor(options ...ServerOption) grpc.UnaryServerInterceptor {
+	s := newGRPCServer(options)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		logger := s.callLogger(ctx, info.FullMethod)
+		ctx = logging.ToContext(ctx, logger)
+		resp, err := handler(ctx, req)
+		s.log(ctx, logger, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is like [UnaryServerInterceptor] for streams.
+// The line is printed when the stream handler returns.
+func StreamServerInterceptor(options ...ServerOption) grpc.StreamServerInterceptor {
+	s := newGRPCServer(options)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		logger := s.callLogger(ss.Context(), info.FullMethod)
+		ctx := logging.ToContext(ss.Context(), logger)
+		err := handler(srv, &loggedServerStream{ServerStream: ss, ctx: ctx})
+		s.log(ctx, logger, start, err)
+		return err
+	}
+}
+
+func newGRPCServer(options []ServerOption) *grpcServer {
+	s := &grpcServer{
+		logger:    slog.Default(),
+		duration:  time.Since,
+		codeLevel: CodeLevel,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+type grpcServer struct {
+	logger    *slog.Logger
+	group     string
+	nextID    func() slog.Attr
+	duration  func(time.Time) time.Duration
+	codeLevel func(codes.Code) slog.Level
+}
+
+func (s *grpcServer) callLogger(ctx context.Context, fullMethod string) *slog.Logger {
+	logger := s.logger.With(slog.Group(s.group, grpcRequestAttr(ctx, fullMethod)))
+	if s.nextID != nil {
+		logger = logger.With(slog.Group(s.group, s.nextID()))
+	}
+	return logger
+}
+
+func (s *grpcServer) log(ctx context.Context, logger *slog.Logger, start time.Time, err error) {
+	code := status.Code(err)
+	attrs := []any{
+		slog.Duration("duration", s.duration(start)),
+		slog.Group("response", slog.String("code", code.String())),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	logger.Log(ctx, s.codeLevel(code), "request served", slog.Group(s.group, attrs...))
+}
+
+func grpcRequestAttr(ctx context.Context, fullMethod string) slog.Attr {
+	attrs := []any{slog.String("method", fullMethod)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	return slog.Group("request", attrs...)
+}
+
+// loggedServerStream overrides the context of the stream,
+// so the handler can obtain the logger with [logging.FromContext].
+type loggedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggedServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,253 @@
+package grpclogging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"log/slog"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zitadel/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func newTestLogger() (out *strings.Builder, logger *slog.Logger) {
+	out = new(strings.Builder)
+	handler := slog.NewJSONHandler(out, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}).WithAttrs([]slog.Attr{slog.String("time", "not")})
+	return out, slog.New(handler)
+}
+
+// testGRPCService returns err from all methods.
+// It fails the call with codes.Internal if
+// no logger was found in the context.
+type testGRPCService struct {
+	err error
+}
+
+func (s *testGRPCService) Unary(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	if _, ok := logging.FromContext(ctx); !ok {
+		return nil, status.Error(codes.Internal, "no logger")
+	}
+	return new(emptypb.Empty), s.err
+}
+
+func (s *testGRPCService) Stream(stream grpc.ServerStream) error {
+	if _, ok := logging.FromContext(stream.Context()); !ok {
+		return status.Error(codes.Internal, "no logger")
+	}
+	if err := stream.SendMsg(new(emptypb.Empty)); err != nil {
+		return err
+	}
+	return s.err
+}
+
+func (s *testGRPCService) ClientStream(stream grpc.ServerStream) error {
+	if _, ok := logging.FromContext(stream.Context()); !ok {
+		return status.Error(codes.Internal, "no logger")
+	}
+	for {
+		err := stream.RecvMsg(new(emptypb.Empty))
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if s.err != nil {
+		return s.err
+	}
+	return stream.SendMsg(new(emptypb.Empty))
+}
+
+var testGRPCServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Test",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Unary",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			in := new(emptypb.Empty)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return srv.(*testGRPCService).Unary(ctx, req.(*emptypb.Empty))
+			}
+			if interceptor == nil {
+				return handler(ctx, in)
+			}
+			return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Test/Unary"}, handler)
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Stream",
+		ServerStreams: true,
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			return srv.(*testGRPCService).Stream(stream)
+		},
+	}, {
+		StreamName:    "ClientStream",
+		ClientStreams: true,
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			return srv.(*testGRPCService).ClientStream(stream)
+		},
+	}},
+}
+
+// newTestGRPCConn starts an in-process server for the test service
+// and returns a client connection to it.
+func newTestGRPCConn(t *testing.T, service *testGRPCService, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(serverOpts...)
+	srv.RegisterService(&testGRPCServiceDesc, service)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialOpts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func callTestGRPCStream(ctx context.Context, conn *grpc.ClientConn) error {
+	stream, err := conn.NewStream(ctx, &testGRPCServiceDesc.Streams[0], "/test.Test/Stream")
+	if err != nil {
+		return err
+	}
+	if err = stream.SendMsg(new(emptypb.Empty)); err != nil {
+		return err
+	}
+	if err = stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		if err = stream.RecvMsg(new(emptypb.Empty)); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// callTestGRPCClientStream sends two messages
+// and receives the single response.
+func callTestGRPCClientStream(ctx context.Context, conn *grpc.ClientConn) error {
+	stream, err := conn.NewStream(ctx, &testGRPCServiceDesc.Streams[1], "/test.Test/ClientStream")
+	if err != nil {
+		return err
+	}
+	for range 2 {
+		if err = stream.SendMsg(new(emptypb.Empty)); err != nil {
+			return err
+		}
+	}
+	if err = stream.CloseSend(); err != nil {
+		return err
+	}
+	return stream.RecvMsg(new(emptypb.Empty))
+}
+
+func TestGRPCServerInterceptors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    string
+		wantErr codes.Code
+	}{
+		{
+			name: "ok",
+			want: `{
+				"level":"INFO",
+				"time":"not",
+				"msg":"request served",
+				"id":"id1",
+				"request":{"method":"%s","peer":"bufconn"},
+				"duration":1000000000,
+				"response":{"code":"OK"}
+			}`,
+		},
+		{
+			name:    "not found",
+			err:     status.Error(codes.NotFound, "not found"),
+			wantErr: codes.NotFound,
+			want: `{
+				"level":"WARN",
+				"time":"not",
+				"msg":"request served",
+				"id":"id1",
+				"request":{"method":"%s","peer":"bufconn"},
+				"duration":1000000000,
+				"response":{"code":"NotFound"},
+				"error":"rpc error: code = NotFound desc = not found"
+			}`,
+		},
+		{
+			name:    "internal",
+			err:     errors.New("oops"),
+			wantErr: codes.Unknown,
+			want: `{
+				"level":"ERROR",
+				"time":"not",
+				"msg":"request served",
+				"id":"id1",
+				"request":{"method":"%s","peer":"bufconn"},
+				"duration":1000000000,
+				"response":{"code":"Unknown"},
+				"error":"oops"
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, logger := newTestLogger()
+			opts := []ServerOption{
+				WithLogger(logger),
+				WithIDFunc(func() slog.Attr {
+					return slog.String("id", "id1")
+				}),
+				WithDurationFunc(func(time.Time) time.Duration {
+					return time.Second
+				}),
+			}
+			conn := newTestGRPCConn(t, &testGRPCService{err: tt.err}, []grpc.ServerOption{
+				grpc.UnaryInterceptor(UnaryServerInterceptor(opts...)),
+				grpc.StreamInterceptor(StreamServerInterceptor(opts...)),
+			})
+
+			t.Run("unary", func(t *testing.T) {
+				out.Reset()
+				err := conn.Invoke(t.Context(), "/test.Test/Unary", new(emptypb.Empty), new(emptypb.Empty))
+				assert.Equal(t, tt.wantErr, status.Code(err))
+				assert.JSONEq(t, fmt.Sprintf(tt.want, "/test.Test/Unary"), out.String())
+			})
+			t.Run("stream", func(t *testing.T) {
+				out.Reset()
+				err := callTestGRPCStream(t.Context(), conn)
+				assert.Equal(t, tt.wantErr, status.Code(err))
+				assert.JSONEq(t, fmt.Sprintf(tt.want, "/test.Test/Stream"), out.String())
+			})
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// baseHandler implements the attribute and group bookkeeping
+// shared by the [slog.Handler] implementations of this package.
+// Handlers embed it and implement Handle, WithAttrs and WithGroup
+// by calling recordAttrs, withAttrs and withGroup.
+type baseHandler struct {
+	opts   slog.HandlerOptions
+	attrs  []groupedAttr
+	groups []string
+}
+
+// groupedAttr is a non-group attribute,
+// with the path of groups it belongs to.
+type groupedAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+// key returns the attribute key, prefixed by its groups
+// and joined by sep.
+func (a groupedAttr) key(sep string) string {
+	if len(a.groups) == 0 {
+		return a.attr.Key
+	}
+	n := len(a.attr.Key)
+	for _, g := range a.groups {
+		n += len(g) + len(sep)
+	}
+	b := make([]byte, 0, n)
+	for _, g := range a.groups {
+		b = append(b, g...)
+		b = append(b, sep...)
+	}
+	return string(append(b, a.attr.Key...))
+}
+
+func newBaseHandler(opts *slog.HandlerOptions) baseHandler {
+	var h baseHandler
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *baseHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h baseHandler) withAttrs(attrs []slog.Attr) baseHandler {
+	h.attrs = slices.Clip(h.attrs)
+	for _, a := range attrs {
+		h.attrs = h.appendAttr(h.attrs, h.groups, a)
+	}
+	return h
+}
+
+func (h baseHandler) withGroup(name string) baseHandler {
+	if name == "" {
+		return h
+	}
+	h.groups = append(slices.Clip(h.groups), name)
+	return h
+}
+
+// recordAttrs returns the attributes from withAttrs, followed by
+// the resolved attributes of the record.
+// Groups are flattened, empty attributes are dropped and
+// ReplaceAttr is applied.
+func (h *baseHandler) recordAttrs(r slog.Record) []groupedAttr {
+	attrs := make([]groupedAttr, len(h.attrs), len(h.attrs)+r.NumAttrs())
+	copy(attrs, h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = h.appendAttr(attrs, h.groups, a)
+		return true
+	})
+	return attrs
+}
+
+func (h *baseHandler) appendAttr(attrs []groupedAttr, groups []string, a slog.Attr) []groupedAttr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		if len(group) == 0 {
+			return attrs
+		}
+		if a.Key != "" {
+			groups = append(slices.Clip(groups), a.Key)
+		}
+		for _, ga := range group {
+			attrs = h.appendAttr(attrs, groups, ga)
+		}
+		return attrs
+	}
+	if h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	return append(attrs, groupedAttr{groups: groups, attr: a})
+}
+
+// builtinAttr applies ReplaceAttr to one of the built-in attributes,
+// like time, level, message or source.
+// ok is false if the attribute should be omitted.
+func (h *baseHandler) builtinAttr(a slog.Attr) (_ slog.Attr, ok bool) {
+	if h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(nil, a)
+		a.Value = a.Value.Resolve()
+	}
+	return a, !a.Equal(slog.Attr{})
+}
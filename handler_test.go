@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_groupedAttr_key(t *testing.T) {
+	assert.Equal(t, "key", groupedAttr{attr: slog.Int("key", 1)}.key("."))
+	assert.Equal(t, "a.b.key", groupedAttr{groups: []string{"a", "b"}, attr: slog.Int("key", 1)}.key("."))
+	assert.Equal(t, "a_key", groupedAttr{groups: []string{"a"}, attr: slog.Int("key", 1)}.key("_"))
+}
+
+func Test_baseHandler(t *testing.T) {
+	h := newBaseHandler(&slog.HandlerOptions{Level: slog.LevelWarn})
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+
+	h = h.withGroup("a").withAttrs([]slog.Attr{slog.Int("b", 1)})
+	h2 := h.withGroup("c")
+	h3 := h.withGroup("d")
+
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)
+	r.AddAttrs(slog.Int("e", 2), slog.Group("f"))
+	assert.Equal(t, []groupedAttr{
+		{groups: []string{"a"}, attr: slog.Int("b", 1)},
+		{groups: []string{"a", "c"}, attr: slog.Int("e", 2)},
+	}, h2.recordAttrs(r))
+	assert.Equal(t, []groupedAttr{
+		{groups: []string{"a"}, attr: slog.Int("b", 1)},
+		{groups: []string{"a", "d"}, attr: slog.Int("e", 2)},
+	}, h3.recordAttrs(r))
+}
@@ -0,0 +1,186 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// clientCall is shared by all roundtrips belonging
+// to the same logical outgoing call, like redirects and retries.
+type clientCall struct {
+	idOnce   sync.Once
+	id       slog.Attr
+	attempts atomic.Int64
+}
+
+// attr returns the call ID, which is obtained
+// from nextID on the first call.
+func (c *clientCall) attr(nextID func() slog.Attr) slog.Attr {
+	c.idOnce.Do(func() {
+		c.id = nextID()
+	})
+	return c.id
+}
+
+type callCtxKeyType struct{}
+
+var callCtxKey callCtxKeyType
+
+// StartClientCall marks the context, so that all requests made
+// with it share the same call ID and attempt counter when logged
+// by a client with [WithClientCallID] or [WithClientRetry].
+// This is useful when retries are done by a wrapper around the [http.Client].
+// Redirects and retries done by [WithClientRetry] are detected without it.
+func StartClientCall(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(callCtxKey).(*clientCall); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, callCtxKey, new(clientCall))
+}
+
+// withClientCall makes sure the request's context carries a call.
+// For redirects, the call of the previous request is reused.
+func withClientCall(req *http.Request) (*http.Request, *clientCall) {
+	if call, ok := req.Context().Value(callCtxKey).(*clientCall); ok {
+		return req, call
+	}
+	call := new(clientCall)
+	if req.Response != nil && req.Response.Request != nil {
+		if prev, ok := req.Response.Request.Context().Value(callCtxKey).(*clientCall); ok {
+			call = prev
+		}
+	}
+	return req.WithContext(context.WithValue(req.Context(), callCtxKey, call)), call
+}
+
+// newCallID returns a random "call_id".
+func newCallID() slog.Attr {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return slog.String("call_id", hex.EncodeToString(b[:]))
+}
+
+// ExponentialBackoff returns a backoff function for [WithClientRetry],
+// which doubles the wait time after every attempt, starting at base
+// and capped at maxWait.
+func ExponentialBackoff(base, maxWait time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < maxWait; i++ {
+			d *= 2
+		}
+		return min(d, maxWait)
+	}
+}
+
+type retryRoundTripper struct {
+	next        http.RoundTripper
+	maxAttempts int
+	backoff     func(attempt int) time.Duration
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (r *retryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, _ = withClientCall(req)
+	for attempt := 1; ; attempt++ {
+		resp, err := r.next.RoundTrip(req)
+		if attempt >= r.maxAttempts || !shouldRetry(req, resp, err) || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		wait := r.backoff(attempt)
+		if resp != nil {
+			after := retryAfter(resp, time.Now())
+			if after > maxRetryAfter {
+				return resp, err
+			}
+			wait = max(wait, after)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+		req = req.Clone(req.Context())
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		if req.Context().Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		// the request might have been processed before the error.
+		return isIdempotent(req)
+	}
+	if !retryableStatus(resp.StatusCode) {
+		return false
+	}
+	// a gateway might have passed the request on before failing,
+	// while 429 and 503 responses are sent instead of processing it.
+	return isIdempotent(req) ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// isIdempotent reports if the request can be sent again
+// without changing the result, which is the case for idempotent
+// methods and requests with an "Idempotency-Key" header.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// maxRetryAfter is the longest Retry-After wait before a retry.
+// Responses asking for a longer wait are returned.
+const maxRetryAfter = time.Minute
+
+// retryAfter returns the time to wait according to the
+// Retry-After header of 429 and 503 responses,
+// which is either in seconds or an HTTP date.
+func retryAfter(resp *http.Response, now time.Time) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	value := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// retryableStatus reports if a request failing with the status code
+// might succeed later.
+func retryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"log/slog"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type callLine struct {
+	ID       string `json:"id"`
+	Attempt  int64  `json:"attempt"`
+	Level    string `json:"level"`
+	Response struct {
+		Status string `json:"status"`
+	} `json:"response"`
+}
+
+func parseCallLines(t *testing.T, out string) []callLine {
+	t.Helper()
+	var lines []callLine
+	for _, s := range strings.Split(strings.TrimSpace(out), "\n") {
+		var line callLine
+		require.NoError(t, json.Unmarshal([]byte(s), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func testIDFunc() func() slog.Attr {
+	var n atomic.Int64
+	return func() slog.Attr {
+		return slog.String("id", strconv.FormatInt(n.Add(1), 10))
+	}
+}
+
+func TestWithClientCallID_redirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/a", http.RedirectHandler("/b", http.StatusFound))
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	out, logger := newTestLogger()
+	c := new(http.Client)
+	EnableHTTPClient(c,
+		WithFallbackLogger(logger),
+		WithClientCallID(testIDFunc()),
+	)
+	_, err := c.Get(ts.URL + "/a")
+	require.NoError(t, err)
+	_, err = c.Get(ts.URL + "/b")
+	require.NoError(t, err)
+
+	lines := parseCallLines(t, out.String())
+	require.Len(t, lines, 3)
+	assert.Equal(t, int64(1), lines[0].Attempt)
+	assert.Equal(t, "302 Found", lines[0].Response.Status)
+	assert.Equal(t, int64(2), lines[1].Attempt)
+	assert.Equal(t, "200 OK", lines[1].Response.Status)
+	assert.Equal(t, int64(1), lines[2].Attempt)
+	assert.Equal(t, "1", lines[0].ID)
+	assert.Equal(t, "1", lines[1].ID)
+	assert.Equal(t, "2", lines[2].ID)
+}
+
+func TestWithClientRetry(t *testing.T) {
+	var requests atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		wantStatus  []string
+	}{
+		{
+			name:        "success",
+			maxAttempts: 3,
+			wantStatus:  []string{"503 Service Unavailable", "503 Service Unavailable", "200 OK"},
+		},
+		{
+			name:        "exhausted",
+			maxAttempts: 2,
+			wantStatus:  []string{"503 Service Unavailable", "503 Service Unavailable"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+			out, logger := newTestLogger()
+			c := new(http.Client)
+			EnableHTTPClient(c,
+				WithFallbackLogger(logger),
+				WithClientCallID(testIDFunc()),
+				WithClientRetry(tt.maxAttempts, func(int) time.Duration { return 0 }),
+			)
+			req, err := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader("body"))
+			require.NoError(t, err)
+			resp, err := c.Do(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus[len(tt.wantStatus)-1], resp.Status)
+
+			lines := parseCallLines(t, out.String())
+			require.Len(t, lines, len(tt.wantStatus))
+			for i, line := range lines {
+				assert.Equal(t, int64(i+1), line.Attempt)
+				assert.Equal(t, tt.wantStatus[i], line.Response.Status)
+				assert.Equal(t, "1", line.ID)
+			}
+		})
+	}
+}
+
+func TestWithClientRetry_canceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c := new(http.Client)
+	EnableHTTPClient(c,
+		WithFallbackLogger(slog.New(slog.DiscardHandler)),
+		WithClientRetry(3, func(int) time.Duration { return time.Hour }),
+	)
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	_, err = c.Do(req)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestStartClientCall(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	out, logger := newTestLogger()
+	c := new(http.Client)
+	EnableHTTPClient(c,
+		WithFallbackLogger(logger),
+		WithClientCallID(testIDFunc()),
+	)
+	ctx := StartClientCall(t.Context())
+	assert.Equal(t, ctx, StartClientCall(ctx))
+	for range 2 {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		_, err = c.Do(req)
+		require.NoError(t, err)
+	}
+	lines := parseCallLines(t, out.String())
+	require.Len(t, lines, 2)
+	assert.Equal(t, "1", lines[1].ID)
+	assert.Equal(t, int64(2), lines[1].Attempt)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(100*time.Millisecond, time.Second)
+	assert.Equal(t, 100*time.Millisecond, backoff(1))
+	assert.Equal(t, 200*time.Millisecond, backoff(2))
+	assert.Equal(t, 800*time.Millisecond, backoff(4))
+	assert.Equal(t, time.Second, backoff(5))
+	assert.Equal(t, time.Second, backoff(100))
+}
+
+// failingTransport counts the requests and fails them.
+type failingTransport struct {
+	requests atomic.Int64
+}
+
+func (rt *failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	rt.requests.Add(1)
+	return nil, errors.New("connection reset")
+}
+
+func TestWithClientRetry_error(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		header       http.Header
+		wantRequests int64
+	}{
+		{"get", http.MethodGet, nil, 3},
+		{"post", http.MethodPost, nil, 1},
+		{"post idempotency key", http.MethodPost, http.Header{"Idempotency-Key": {"key"}}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := new(failingTransport)
+			c := &http.Client{Transport: transport}
+			EnableHTTPClient(c,
+				WithFallbackLogger(slog.New(slog.DiscardHandler)),
+				WithClientRetry(3, func(int) time.Duration { return 0 }),
+			)
+			req, err := http.NewRequest(tt.method, "http://example.com", nil)
+			require.NoError(t, err)
+			req.Header = tt.header
+			_, err = c.Do(req)
+			require.Error(t, err)
+			assert.Equal(t, tt.wantRequests, transport.requests.Load())
+		})
+	}
+}
+
+func TestWithClientRetry_status(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statusCode   int
+		retryAfter   string
+		wantRequests int64
+	}{
+		{"get bad gateway", http.MethodGet, http.StatusBadGateway, "", 3},
+		{"post bad gateway", http.MethodPost, http.StatusBadGateway, "", 1},
+		{"post gateway timeout", http.MethodPost, http.StatusGatewayTimeout, "", 1},
+		{"post too many requests", http.MethodPost, http.StatusTooManyRequests, "", 3},
+		{"long retry after", http.MethodGet, http.StatusServiceUnavailable, "86400", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int64
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer ts.Close()
+
+			c := new(http.Client)
+			EnableHTTPClient(c,
+				WithFallbackLogger(slog.New(slog.DiscardHandler)),
+				WithClientRetry(3, func(int) time.Duration { return 0 }),
+			)
+			req, err := http.NewRequest(tt.method, ts.URL, nil)
+			require.NoError(t, err)
+			resp, err := c.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.statusCode, resp.StatusCode)
+			assert.Equal(t, tt.wantRequests, requests.Load())
+		})
+	}
+}
+
+func Test_retryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		statusCode int
+		header     string
+		want       time.Duration
+	}{
+		{"seconds", http.StatusTooManyRequests, "2", 2 * time.Second},
+		{"date", http.StatusServiceUnavailable, now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{"past date", http.StatusServiceUnavailable, now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"invalid", http.StatusServiceUnavailable, "soon", 0},
+		{"other status", http.StatusBadGateway, "2", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.statusCode, Header: http.Header{"Retry-After": {tt.header}}}
+			assert.Equal(t, tt.want, retryAfter(resp, now))
+		})
+	}
+}
@@ -2,7 +2,10 @@ package logging
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"net/http/httptrace"
+	"sync"
 	"time"
 
 	"log/slog"
@@ -50,11 +53,117 @@ func WithClientResponseAttr(responseToAttr func(*http.Response) slog.Attr) Clien
 	}
 }
 
+// WithClientSlowThreshold prints the request roundtrip line at WARN level
+// with a slow=true attribute, when the roundtrip took longer than d.
+func WithClientSlowThreshold(d time.Duration) ClientLoggerOption {
+	return func(lrt *logRountTripper) {
+		lrt.slowThreshold = constantThreshold(d)
+	}
+}
+
+// WithClientSlowThresholdFunc is like [WithClientSlowThreshold],
+// but obtains the threshold for each request.
+// This allows different thresholds per route, see [PathSlowThresholds].
+func WithClientSlowThresholdFunc(threshold func(*http.Request) time.Duration) ClientLoggerOption {
+	return func(lrt *logRountTripper) {
+		lrt.slowThreshold = threshold
+	}
+}
+
+// WithClientTrace installs a [httptrace.ClientTrace] on each request
+// and adds a "timing" group with the DNS, connect, TLS handshake and
+// time-to-first-byte durations, and if the connection was reused.
+// Phases which did not happen, for example DNS on a reused connection,
+// are omitted.
+func WithClientTrace() ClientLoggerOption {
+	return func(lrt *logRountTripper) {
+		lrt.trace = true
+	}
+}
+
+// WithClientLogOnClose defers the log line until the response body
+// is closed. The duration then includes reading the body and
+// a "body" group with the amount of bytes read is added.
+// An error encountered while reading the body is logged at WARN level.
+// Bodies which are never closed are never logged.
+// Responses with status 101 Switching Protocols are logged immediately.
+func WithClientLogOnClose() ClientLoggerOption {
+	return func(lrt *logRountTripper) {
+		lrt.logOnClose = true
+	}
+}
+
+// WithClientCallID adds the attribute returned by nextID and
+// an "attempt" counter to the log lines.
+// The ID is shared by all requests of the same logical call,
+// which includes redirects followed by the client,
+// retries done by [WithClientRetry] and
+// requests made with a context from [StartClientCall].
+func WithClientCallID(nextID func() slog.Attr) ClientLoggerOption {
+	return func(lrt *logRountTripper) {
+		lrt.nextID = nextID
+	}
+}
+
+// WithClientRetry retries requests failing with
+// a 429, 502, 503 or 504 status code up to maxAttempts,
+// waiting for backoff between attempts. See [ExponentialBackoff].
+// A longer Retry-After header of 429 and 503 responses is honored
+// up to a minute, responses asking for a longer wait are returned.
+// Requests failing with an error, a 502 or a 504 status code are
+// only retried if they use an idempotent method or have an
+// "Idempotency-Key" header, as the server might have processed them.
+// Each attempt is logged. If [WithClientCallID] is not set,
+// a random "call_id" attribute is used.
+// Requests with a body are only retried if GetBody is set.
+func WithClientRetry(maxAttempts int, backoff func(attempt int) time.Duration) ClientLoggerOption {
+	return func(lrt *logRountTripper) {
+		lrt.retry = &retryRoundTripper{
+			maxAttempts: maxAttempts,
+			backoff:     backoff,
+		}
+	}
+}
+
+// WithClientStatusLevel sets the level of the roundtrip line
+// based on the response status code. By default all responses
+// are logged at INFO level. See [StatusLevel] for a common mapping.
+// Slow requests and body read errors are never logged
+// below WARN level.
+func WithClientStatusLevel(statusLevel func(statusCode int) slog.Level) ClientLoggerOption {
+	return func(lrt *logRountTripper) {
+		lrt.statusLevel = statusLevel
+	}
+}
+
+// StatusLevel maps 5xx status codes to ERROR,
+// 4xx status codes to WARN and all others to INFO.
+func StatusLevel(statusCode int) slog.Level {
+	switch {
+	case statusCode >= 500:
+		return slog.LevelError
+	case statusCode >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithClientFilter only logs roundtrips for which all filters
+// return true. The option may be passed multiple times.
+// To skip individual requests, use [WithoutClientLogging].
+func WithClientFilter(filter ClientFilter) ClientLoggerOption {
+	return func(lrt *logRountTripper) {
+		lrt.filters = append(lrt.filters, filter)
+	}
+}
+
 // EnableHTTPClient adds slog functionality to the HTTP client.
 // It attempts to obtain a logger with [FromContext].
 // If no logger is in the context, it tries to use a fallback logger,
 // which might be set by [WithFallbackLogger].
-// If no logger was found finally, the Transport is
+// If no logger was found finally, or the context was
+// passed to [WithoutClientLogging], the Transport is
 // executed without logging.
 func EnableHTTPClient(c *http.Client, opts ...ClientLoggerOption) {
 	lrt := &logRountTripper{
@@ -69,7 +178,15 @@ func EnableHTTPClient(c *http.Client, opts ...ClientLoggerOption) {
 	for _, opt := range opts {
 		opt(lrt)
 	}
-	c.Transport = lrt
+	if lrt.retry == nil {
+		c.Transport = lrt
+		return
+	}
+	if lrt.nextID == nil {
+		lrt.nextID = newCallID
+	}
+	lrt.retry.next = lrt
+	c.Transport = lrt.retry
 }
 
 type logRountTripper struct {
@@ -80,29 +197,147 @@ type logRountTripper struct {
 	group     string
 	reqToAttr func(*http.Request) slog.Attr
 	resToAttr func(*http.Response) slog.Attr
+
+	slowThreshold func(*http.Request) time.Duration
+	trace         bool
+	logOnClose    bool
+	nextID        func() slog.Attr
+	retry         *retryRoundTripper
+	statusLevel   func(statusCode int) slog.Level
+	filters       []ClientFilter
 }
 
 // RoundTrip implements [http.RoundTripper].
 func (l *logRountTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if ClientLoggingDisabled(req.Context()) {
+		return l.next.RoundTrip(req)
+	}
 	logger, ok := l.fromContextOrFallback(req.Context())
 	if !ok {
 		return l.next.RoundTrip(req)
 	}
-	start := time.Now()
+	rt := &roundtrip{
+		lrt:    l,
+		logger: logger,
+		start:  time.Now(),
+	}
+	if l.nextID != nil {
+		req, rt.call = withClientCall(req)
+		rt.attempt = rt.call.attempts.Add(1)
+	}
+	if l.trace {
+		rt.timing = newClientTiming(time.Now)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), rt.timing.trace()))
+	}
+	rt.req = req
 
 	resp, err := l.next.RoundTrip(req)
-	logger = logger.WithGroup(l.group).With(
-		l.reqToAttr(req),
-		slog.Duration("duration", l.duration(start)),
-	)
 	if err != nil {
-		logger.Error("request roundtrip", "error", err)
+		rt.log(nil, err)
 		return resp, err
 	}
-	logger.Info("request roundtrip", l.resToAttr(resp))
+	// the body of a 101 response is an [io.ReadWriteCloser]
+	// of the upgraded connection, which must not be hidden.
+	if l.logOnClose && resp.Body != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body = &loggedBody{
+			ReadCloser: resp.Body,
+			done: func(read int64, err error) {
+				rt.log(resp, err, slog.Group("body", slog.Int64("read", read)))
+			},
+		}
+		return resp, nil
+	}
+	rt.log(resp, nil)
 	return resp, nil
 }
 
+// roundtrip holds the state of a single request
+// passing through the [logRountTripper].
+type roundtrip struct {
+	lrt    *logRountTripper
+	logger *slog.Logger
+	req    *http.Request
+	start  time.Time
+	timing *clientTiming
+
+	call    *clientCall
+	attempt int64
+}
+
+// log prints the roundtrip line.
+// If resp is nil, err is the error returned by the next RoundTripper.
+// Otherwise err is an error encountered reading the response body.
+func (rt *roundtrip) log(resp *http.Response, err error, attrs ...any) {
+	l := rt.lrt
+	for _, filter := range l.filters {
+		if !filter(rt.req, resp) {
+			return
+		}
+	}
+	duration := l.duration(rt.start)
+	logger := rt.logger.WithGroup(l.group).With(
+		l.reqToAttr(rt.req),
+		slog.Duration("duration", duration),
+	)
+	if rt.call != nil {
+		logger = logger.With(
+			rt.call.attr(l.nextID),
+			slog.Int64("attempt", rt.attempt),
+		)
+	}
+	if rt.timing != nil {
+		logger = logger.With(rt.timing.Attr())
+	}
+	level := slog.LevelInfo
+	if isSlow(l.slowThreshold, rt.req, duration) {
+		logger = logger.With(slog.Bool("slow", true))
+		level = slog.LevelWarn
+	}
+	// the group is already added by WithGroup
+	ctx := withHTTPRecord(rt.req.Context(), "")
+	if resp == nil {
+		logger.ErrorContext(ctx, "request roundtrip", "error", err)
+		return
+	}
+	if l.statusLevel != nil {
+		level = max(level, l.statusLevel(resp.StatusCode))
+	}
+	attrs = append(attrs, l.resToAttr(resp))
+	if err != nil {
+		attrs = append(attrs, "error", err)
+		level = max(level, slog.LevelWarn)
+	}
+	logger.Log(ctx, level, "request roundtrip", attrs...)
+}
+
+// loggedBody counts the bytes read from a response body
+// and calls done once, when the body is closed.
+type loggedBody struct {
+	io.ReadCloser
+
+	read int64
+	err  error
+	once sync.Once
+	done func(read int64, err error)
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.done(b.read, b.err)
+	})
+	return err
+}
+
 func (l *logRountTripper) fromContextOrFallback(ctx context.Context) (*slog.Logger, bool) {
 	if logger, ok := FromContext(ctx); ok {
 		return logger, ok
@@ -1,13 +1,18 @@
 package logging
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"log/slog"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -20,11 +25,12 @@ func (errRountripper) RoundTrip(*http.Request) (*http.Response, error) {
 
 func Test_EnableHTTPClient(t *testing.T) {
 	tests := []struct {
-		name      string
-		transport http.RoundTripper
-		fromCtx   bool
-		wantErr   error
-		wantLog   string
+		name          string
+		transport     http.RoundTripper
+		fromCtx       bool
+		slowThreshold time.Duration
+		wantErr       error
+		wantLog       string
 	}{
 		{
 			name:      "nil transport / default",
@@ -56,6 +62,23 @@ func Test_EnableHTTPClient(t *testing.T) {
 				}
 			}`,
 		},
+		{
+			name:          "slow",
+			transport:     http.DefaultTransport,
+			slowThreshold: time.Millisecond,
+			wantLog: `{
+				"level":"WARN",
+				"msg":"request roundtrip",
+				"time":"not",
+				"request":{"method":"GET","url":"%s"},
+				"duration":1000000000,
+				"slow":true,
+				"response":{
+					"status":"200 OK",
+					"content_length":14
+				}
+			}`,
+		},
 		{
 			name:      "roundtrip error",
 			transport: errRountripper{},
@@ -101,6 +124,7 @@ func Test_EnableHTTPClient(t *testing.T) {
 				}),
 				WithClientRequestAttr(requestToAttr),
 				WithClientResponseAttr(responseToAttr),
+				WithClientSlowThreshold(tt.slowThreshold),
 			)
 
 			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -122,3 +146,174 @@ func Test_EnableHTTPClient(t *testing.T) {
 		})
 	}
 }
+
+type bodyRoundTripper struct {
+	body io.ReadCloser
+}
+
+func (rt bodyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		ContentLength: -1,
+		Body:          rt.body,
+		Request:       req,
+	}, nil
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestWithClientLogOnClose(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    io.Reader
+		wantLog string
+	}{
+		{
+			name: "ok",
+			body: strings.NewReader("Hello, client"),
+			wantLog: `{
+				"level":"INFO",
+				"msg":"request roundtrip",
+				"time":"not",
+				"request":{"method":"GET","url":"http://example.com"},
+				"duration":1000000000,
+				"body":{"read":13},
+				"response":{
+					"status":"200 OK",
+					"content_length":-1
+				}
+			}`,
+		},
+		{
+			name: "read error",
+			body: io.MultiReader(strings.NewReader("Hello"), errReader{}),
+			wantLog: `{
+				"level":"WARN",
+				"msg":"request roundtrip",
+				"time":"not",
+				"request":{"method":"GET","url":"http://example.com"},
+				"duration":1000000000,
+				"body":{"read":5},
+				"error":"unexpected EOF",
+				"response":{
+					"status":"200 OK",
+					"content_length":-1
+				}
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, logger := newTestLogger()
+			c := &http.Client{
+				Transport: bodyRoundTripper{io.NopCloser(tt.body)},
+			}
+			EnableHTTPClient(c,
+				WithFallbackLogger(logger),
+				WithClientDurationFunc(func(t time.Time) time.Duration {
+					return time.Second
+				}),
+				WithClientLogOnClose(),
+			)
+
+			resp, err := c.Get("http://example.com")
+			require.NoError(t, err)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			assert.Empty(t, out.String())
+
+			require.NoError(t, resp.Body.Close())
+			require.NoError(t, resp.Body.Close())
+			assert.JSONEq(t, tt.wantLog, out.String())
+		})
+	}
+}
+
+// upgradeRoundTripper returns a 101 response,
+// with the connection as body.
+type upgradeRoundTripper struct {
+	conn io.ReadWriteCloser
+}
+
+func (rt upgradeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Status:     "101 Switching Protocols",
+		StatusCode: http.StatusSwitchingProtocols,
+		Body:       rt.conn,
+		Request:    req,
+	}, nil
+}
+
+func TestWithClientLogOnClose_switchingProtocols(t *testing.T) {
+	out, logger := newTestLogger()
+	client, server := net.Pipe()
+	defer server.Close()
+	c := &http.Client{Transport: upgradeRoundTripper{client}}
+	EnableHTTPClient(c, WithFallbackLogger(logger), WithClientLogOnClose())
+
+	resp, err := c.Get("http://example.com")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Implements(t, (*io.ReadWriteCloser)(nil), resp.Body)
+	assert.Contains(t, out.String(), `"status":"101 Switching Protocols"`)
+}
+
+type ctxTestKey struct{}
+
+// recordHandler records the level and context passed to Handle.
+type recordHandler struct {
+	levels []slog.Level
+	ctxs   []context.Context
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.levels = append(h.levels, r.Level)
+	h.ctxs = append(h.ctxs, ctx)
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestWithClientStatusLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		transport http.RoundTripper
+		want      slog.Level
+	}{
+		{"ok", http.StatusOK, nil, slog.LevelInfo},
+		{"not found", http.StatusNotFound, nil, slog.LevelWarn},
+		{"internal error", http.StatusInternalServerError, nil, slog.LevelError},
+		{"roundtrip error", http.StatusOK, errRountripper{}, slog.LevelError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			handler := new(recordHandler)
+			c := &http.Client{Transport: tt.transport}
+			EnableHTTPClient(c,
+				WithFallbackLogger(slog.New(handler)),
+				WithClientStatusLevel(StatusLevel),
+			)
+			ctx := context.WithValue(t.Context(), ctxTestKey{}, "value")
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+			require.NoError(t, err)
+			_, _ = c.Do(req)
+
+			require.Len(t, handler.levels, 1)
+			assert.Equal(t, tt.want, handler.levels[0])
+			assert.Equal(t, "value", handler.ctxs[0].Value(ctxTestKey{}))
+		})
+	}
+}
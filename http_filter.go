@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ClientFilter decides if a roundtrip is logged.
+// The response is nil if the roundtrip returned an error.
+// See [WithClientFilter].
+type ClientFilter func(req *http.Request, resp *http.Response) bool
+
+// SkipHosts returns a filter which skips requests to one of hosts.
+// Hosts are compared to the URL's host without port.
+func SkipHosts(hosts ...string) ClientFilter {
+	return func(req *http.Request, _ *http.Response) bool {
+		return !slices.Contains(hosts, req.URL.Hostname())
+	}
+}
+
+// SkipPathPrefixes returns a filter which skips requests
+// where the URL path starts with one of prefixes.
+func SkipPathPrefixes(prefixes ...string) ClientFilter {
+	return func(req *http.Request, _ *http.Response) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(req.URL.Path, prefix) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// SkipMethods returns a filter which skips requests
+// with one of methods.
+func SkipMethods(methods ...string) ClientFilter {
+	return func(req *http.Request, _ *http.Response) bool {
+		return !slices.Contains(methods, req.Method)
+	}
+}
+
+// SkipStatusCodes returns a filter which skips responses
+// with one of statusCodes. Failed roundtrips are not skipped.
+func SkipStatusCodes(statusCodes ...int) ClientFilter {
+	return func(_ *http.Request, resp *http.Response) bool {
+		return resp == nil || !slices.Contains(statusCodes, resp.StatusCode)
+	}
+}
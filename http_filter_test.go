@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientFilters(t *testing.T) {
+	get := httptest.NewRequest(http.MethodGet, "http://metadata.internal:8080/computeMetadata/v1/", nil)
+	post := httptest.NewRequest(http.MethodPost, "https://idp.example.com/oauth/token", nil)
+	ok := &http.Response{StatusCode: http.StatusOK}
+	notFound := &http.Response{StatusCode: http.StatusNotFound}
+
+	tests := []struct {
+		name   string
+		filter ClientFilter
+		req    *http.Request
+		resp   *http.Response
+		want   bool
+	}{
+		{"host skipped", SkipHosts("metadata.internal"), get, ok, false},
+		{"host logged", SkipHosts("metadata.internal"), post, ok, true},
+		{"path skipped", SkipPathPrefixes("/v1/traces", "/computeMetadata/"), get, ok, false},
+		{"path logged", SkipPathPrefixes("/v1/traces", "/computeMetadata/"), post, ok, true},
+		{"method skipped", SkipMethods(http.MethodGet), get, ok, false},
+		{"method logged", SkipMethods(http.MethodGet), post, ok, true},
+		{"status skipped", SkipStatusCodes(http.StatusNotFound), get, notFound, false},
+		{"status logged", SkipStatusCodes(http.StatusNotFound), get, ok, true},
+		{"status error logged", SkipStatusCodes(http.StatusNotFound), get, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter(tt.req, tt.resp))
+		})
+	}
+}
+
+func TestWithClientFilter(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	out, logger := newTestLogger()
+	c := new(http.Client)
+	EnableHTTPClient(c,
+		WithFallbackLogger(logger),
+		WithClientFilter(SkipPathPrefixes("/metrics")),
+		WithClientFilter(SkipMethods(http.MethodHead)),
+	)
+
+	_, err := c.Get(ts.URL + "/metrics")
+	require.NoError(t, err)
+	_, err = c.Head(ts.URL)
+	require.NoError(t, err)
+	req, err := http.NewRequestWithContext(WithoutClientLogging(t.Context()), http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	_, err = c.Do(req)
+	require.NoError(t, err)
+	assert.Empty(t, out.String())
+
+	_, err = c.Get(ts.URL)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "request roundtrip")
+}
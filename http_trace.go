@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// clientTiming collects the timestamps of a single roundtrip
+// from [httptrace.ClientTrace] hooks.
+// The hooks may be called from different goroutines,
+// so all fields are protected by mu.
+type clientTiming struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+func newClientTiming(now func() time.Time) *clientTiming {
+	return &clientTiming{
+		now:   now,
+		start: now(),
+	}
+}
+
+func (t *clientTiming) set(ts *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*ts = t.now()
+}
+
+func (t *clientTiming) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.reused = info.Reused
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.set(&t.dnsDone) },
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// multiple dials may race, keep the first.
+			if t.connectStart.IsZero() {
+				t.connectStart = t.now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.set(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart:    func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.set(&t.tlsDone) },
+		GotFirstResponseByte: func() { t.set(&t.firstByte) },
+	}
+}
+
+// Attr returns a "timing" group with the durations of
+// the phases which happened during the roundtrip.
+func (t *clientTiming) Attr() slog.Attr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	attrs := make([]any, 0, 5)
+	if !t.dnsStart.IsZero() && !t.dnsDone.IsZero() {
+		attrs = append(attrs, slog.Duration("dns", t.dnsDone.Sub(t.dnsStart)))
+	}
+	if !t.connectStart.IsZero() && !t.connectDone.IsZero() {
+		attrs = append(attrs, slog.Duration("connect", t.connectDone.Sub(t.connectStart)))
+	}
+	if !t.tlsStart.IsZero() && !t.tlsDone.IsZero() {
+		attrs = append(attrs, slog.Duration("tls_handshake", t.tlsDone.Sub(t.tlsStart)))
+	}
+	if !t.firstByte.IsZero() {
+		attrs = append(attrs, slog.Duration("ttfb", t.firstByte.Sub(t.start)))
+	}
+	attrs = append(attrs, slog.Bool("reused", t.reused))
+	return slog.Group("timing", attrs...)
+}
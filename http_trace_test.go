@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_clientTiming(t *testing.T) {
+	var clock time.Time
+	timing := newClientTiming(func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	})
+	trace := timing.trace()
+	trace.DNSStart(httptrace.DNSStartInfo{})
+	trace.DNSDone(httptrace.DNSDoneInfo{})
+	trace.ConnectStart("tcp", "127.0.0.1:443")
+	trace.ConnectDone("tcp", "127.0.0.1:443", nil)
+	trace.TLSHandshakeStart()
+	trace.TLSHandshakeDone(tls.ConnectionState{}, nil)
+	trace.GotConn(httptrace.GotConnInfo{})
+	trace.GotFirstResponseByte()
+
+	out, logger := newTestLogger()
+	logger.Info("test", timing.Attr())
+	assert.JSONEq(t, `{
+		"level":"INFO",
+		"time":"not",
+		"msg":"test",
+		"timing":{
+			"dns":1000000000,
+			"connect":1000000000,
+			"tls_handshake":1000000000,
+			"ttfb":7000000000,
+			"reused":false
+		}
+	}`, out.String())
+}
+
+func TestWithClientTrace(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "Hello, client")
+	}))
+	defer ts.Close()
+
+	c := ts.Client()
+	EnableHTTPClient(c, WithClientTrace())
+
+	for _, wantReused := range []bool{false, true} {
+		out, logger := newTestLogger()
+		req, err := http.NewRequestWithContext(ToContext(t.Context(), logger), http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		_, err = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		var got struct {
+			Timing map[string]any `json:"timing"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out.String()), &got))
+		assert.Equal(t, wantReused, got.Timing["reused"])
+		assert.Contains(t, got.Timing, "ttfb")
+		if wantReused {
+			assert.NotContains(t, got.Timing, "connect")
+			assert.NotContains(t, got.Timing, "tls_handshake")
+		} else {
+			assert.Contains(t, got.Timing, "connect")
+			assert.Contains(t, got.Timing, "tls_handshake")
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package logging
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JournaldConfig configures the [JournaldHandler].
+type JournaldConfig struct {
+	// Socket is the path of the journal socket,
+	// defaults to "/run/systemd/journal/socket".
+	Socket string `json:"socket"`
+	// Identifier is sent as SYSLOG_IDENTIFIER,
+	// defaults to the name of the executable.
+	Identifier string `json:"identifier"`
+}
+
+// JournaldHandler is a [Sink] writing records to the systemd journal
+// using the native datagram protocol.
+// Entries too large for a datagram are passed in a sealed memfd.
+//
+// The message is sent as MESSAGE and the level as PRIORITY,
+// using the syslog severities of [SyslogHandler].
+// Attribute keys are converted to journal field names by joining
+// groups with an underscore, converting them to upper case and
+// replacing invalid characters. With AddSource, or from the "caller"
+// field set for logrus, the source is sent as CODE_FILE and CODE_LINE.
+// The journal assigns the timestamp when receiving the entry.
+//
+// Entries are written synchronously, so Flush does nothing.
+type JournaldHandler struct {
+	baseHandler
+	writer *journalWriter
+}
+
+// NewJournaldHandler creates a [JournaldHandler] connected to
+// the journal socket. If opts is nil, the default options are used.
+func NewJournaldHandler(config JournaldConfig, opts *slog.HandlerOptions) (*JournaldHandler, error) {
+	if config.Socket == "" {
+		config.Socket = "/run/systemd/journal/socket"
+	}
+	if config.Identifier == "" {
+		config.Identifier = filepath.Base(os.Args[0])
+	}
+	w := &journalWriter{config: config}
+	if err := w.dial(); err != nil {
+		return nil, err
+	}
+	return &JournaldHandler{
+		baseHandler: newBaseHandler(opts),
+		writer:      w,
+	}, nil
+}
+
+func (h *JournaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &JournaldHandler{baseHandler: h.withAttrs(attrs), writer: h.writer}
+}
+
+func (h *JournaldHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &JournaldHandler{baseHandler: h.withGroup(name), writer: h.writer}
+}
+
+func (h *JournaldHandler) Handle(_ context.Context, r slog.Record) error {
+	b := appendJournalField(nil, "MESSAGE", r.Message)
+	b = appendJournalField(b, "PRIORITY", strconv.Itoa(syslogSeverity(r.Level)))
+	b = appendJournalField(b, "SYSLOG_IDENTIFIER", h.writer.config.Identifier)
+
+	var source *slog.Source
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		source = &slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}
+	}
+	for _, a := range h.recordAttrs(r) {
+		if source == nil && len(a.groups) == 0 && a.attr.Key == "caller" {
+			if caller, ok := parseCaller(a.attr.Value); ok {
+				source = caller
+				continue
+			}
+		}
+		b = appendJournalField(b, journalFieldName(a.key("_")), syslogValue(a.attr.Value))
+	}
+	if source != nil {
+		b = appendJournalField(b, "CODE_FILE", source.File)
+		b = appendJournalField(b, "CODE_LINE", strconv.Itoa(source.Line))
+		if source.Function != "" {
+			b = appendJournalField(b, "CODE_FUNC", source.Function)
+		}
+	}
+	return h.writer.write(b)
+}
+
+// Flush does nothing, as entries are written synchronously.
+func (h *JournaldHandler) Flush(context.Context) error {
+	return nil
+}
+
+// Close closes the connection to the journal.
+func (h *JournaldHandler) Close(context.Context) error {
+	return h.writer.close()
+}
+
+type journalWriter struct {
+	config JournaldConfig
+
+	mu     sync.Mutex
+	conn   *net.UnixConn
+	closed bool
+}
+
+func (w *journalWriter) dial() (err error) {
+	w.conn, err = net.DialUnix("unixgram", nil, &net.UnixAddr{Name: w.config.Socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("logging: journald: %w", err)
+	}
+	return nil
+}
+
+// write sends the entry, using a memfd for entries which are
+// too large for a datagram. The connection is re-established
+// once, for example when journald was restarted.
+func (w *journalWriter) write(entry []byte) (err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrClosed
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if err = w.dial(); err != nil {
+				return err
+			}
+		}
+		_, err = w.conn.Write(entry)
+		if isMessageTooLarge(err) {
+			err = sendJournalFile(w.conn, entry)
+			break
+		}
+		if err == nil {
+			return nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+	if err != nil {
+		return fmt.Errorf("logging: journald: %w", err)
+	}
+	return nil
+}
+
+func (w *journalWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrClosed
+	}
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	return w.conn.Close()
+}
+
+// appendJournalField appends a field in the native protocol.
+// Values containing a newline are prefixed with
+// their length as 64 bit little endian integer.
+func appendJournalField(b []byte, name, value string) []byte {
+	b = append(b, name...)
+	if !strings.ContainsRune(value, '\n') {
+		b = append(b, '=')
+		b = append(b, value...)
+		return append(b, '\n')
+	}
+	b = append(b, '\n')
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// journalFieldName converts key into a valid journal field name
+// of at most 64 upper case letters, digits and underscores,
+// not starting with an underscore or digit.
+func journalFieldName(key string) string {
+	key = strings.TrimLeft(key, "_0123456789")
+	if key == "" {
+		return "X"
+	}
+	b := make([]byte, 0, min(len(key), 64))
+	for i := 0; i < len(key) && i < 64; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		default:
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+var errJournalFileUnsupported = errors.New("entry too large and memfd is not supported")
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestJournaldHandler_memfd(t *testing.T) {
+	conn, path := listenJournal(t)
+	h, err := NewJournaldHandler(JournaldConfig{Socket: path, Identifier: "app"}, nil)
+	require.NoError(t, err)
+	defer h.Close(context.Background())
+
+	message := strings.Repeat("x", 1<<20)
+	slog.New(h).Info(message)
+
+	oob := make([]byte, unix.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(nil, oob)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	fds, err := unix.ParseUnixRights(&msgs[0])
+	require.NoError(t, err)
+	require.Len(t, fds, 1)
+
+	f := os.NewFile(uintptr(fds[0]), "entry")
+	defer f.Close()
+	seals, err := unix.FcntlInt(f.Fd(), unix.F_GET_SEALS, 0)
+	require.NoError(t, err)
+	assert.NotZero(t, seals&unix.F_SEAL_WRITE)
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	entry, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"MESSAGE":           message,
+		"PRIORITY":          "6",
+		"SYSLOG_IDENTIFIER": "app",
+	}, parseJournalEntry(t, entry))
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package logging
+
+import "net"
+
+func isMessageTooLarge(error) bool {
+	return false
+}
+
+func sendJournalFile(*net.UnixConn, []byte) error {
+	return errJournalFileUnsupported
+}
@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listenJournal binds a unixgram socket standing in for journald.
+func listenJournal(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	return conn, path
+}
+
+// parseJournalEntry decodes an entry of the native protocol.
+func parseJournalEntry(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(b) > 0 {
+		i := bytes.IndexAny(b, "=\n")
+		require.GreaterOrEqual(t, i, 0)
+		name := string(b[:i])
+		if b[i] == '=' {
+			end := bytes.IndexByte(b, '\n')
+			fields[name] = string(b[i+1 : end])
+			b = b[end+1:]
+			continue
+		}
+		n := binary.LittleEndian.Uint64(b[i+1:])
+		b = b[i+9:]
+		fields[name] = string(b[:n])
+		require.Equal(t, byte('\n'), b[n])
+		b = b[n+1:]
+	}
+	return fields
+}
+
+func readJournalEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return parseJournalEntry(t, buf[:n])
+}
+
+func TestJournaldHandler(t *testing.T) {
+	conn, path := listenJournal(t)
+	h, err := NewJournaldHandler(JournaldConfig{Socket: path, Identifier: "app"}, &slog.HandlerOptions{AddSource: true})
+	require.NoError(t, err)
+	defer h.Close(context.Background())
+
+	slog.New(h).With("request-id", 1).WithGroup("http").Warn("line1\nline2", "status", 404, "_trusted", true)
+	got := readJournalEntry(t, conn)
+	assert.Contains(t, got["CODE_FILE"], "journald_test.go")
+	assert.NotEmpty(t, got["CODE_LINE"])
+	assert.Contains(t, got["CODE_FUNC"], "TestJournaldHandler")
+	delete(got, "CODE_FILE")
+	delete(got, "CODE_LINE")
+	delete(got, "CODE_FUNC")
+	assert.Equal(t, map[string]string{
+		"MESSAGE":           "line1\nline2",
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "app",
+		"REQUEST_ID":        "1",
+		"HTTP_STATUS":       "404",
+		"HTTP__TRUSTED":     "true",
+	}, got)
+}
+
+func TestJournaldHandler_logrus(t *testing.T) {
+	conn, path := listenJournal(t)
+	h, err := NewJournaldHandler(JournaldConfig{Socket: path, Identifier: "app"}, nil)
+	require.NoError(t, err)
+	defer h.Close(context.Background())
+
+	l := logrus.New()
+	l.Out = io.Discard
+	l.AddHook(NewSlogHook(h))
+	l.WithField("caller", "/src/main.go:42").Error("failed")
+	assert.Equal(t, map[string]string{
+		"MESSAGE":           "failed",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "app",
+		"CODE_FILE":         "/src/main.go",
+		"CODE_LINE":         "42",
+	}, readJournalEntry(t, conn))
+}
+
+func TestJournaldHandler_closed(t *testing.T) {
+	_, path := listenJournal(t)
+	h, err := NewJournaldHandler(JournaldConfig{Socket: path}, nil)
+	require.NoError(t, err)
+	require.NoError(t, h.Close(context.Background()))
+	assert.ErrorIs(t, h.Handle(context.Background(), slog.Record{}), ErrClosed)
+
+	_, err = NewJournaldHandler(JournaldConfig{Socket: filepath.Join(t.TempDir(), "missing")}, nil)
+	assert.Error(t, err)
+}
+
+func Test_journalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"message":   "MESSAGE",
+		"http.path": "HTTP_PATH",
+		"_secret":   "SECRET",
+		"1st":       "ST",
+		"___":       "X",
+		"ü":         "__",
+		"a" + string(bytes.Repeat([]byte("b"), 70)): "A" + string(bytes.Repeat([]byte("B"), 63)),
+	}
+	for key, want := range tests {
+		assert.Equal(t, want, journalFieldName(key), key)
+	}
+}
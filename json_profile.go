@@ -0,0 +1,297 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// jsonRecord is the common representation of slog records
+// and logrus entries, used to build JSON objects
+// of a specific schema, like [NewGCPHandler].
+type jsonRecord struct {
+	time    time.Time
+	level   slog.Level
+	message string
+	source  *slog.Source
+	// attrs holds nested maps for groups
+	// and resolved [slog.Value] leaves.
+	attrs map[string]any
+	// http is the path of the attributes logged by [Middleware]
+	// and [EnableHTTPClient] in attrs, nil for other records.
+	http []string
+}
+
+// httpPath returns the path of the HTTP attribute at keys.
+func (r *jsonRecord) httpPath(keys ...string) []string {
+	return append(slices.Clip(r.http), keys...)
+}
+
+// jsonProfile builds the JSON object of a record.
+// It may remove attributes it maps to other fields.
+type jsonProfile func(r *jsonRecord) map[string]any
+
+// jsonProfileHandler is a [slog.Handler] writing
+// objects built by a jsonProfile.
+type jsonProfileHandler struct {
+	baseHandler
+	mu      *sync.Mutex
+	w       io.Writer
+	profile jsonProfile
+}
+
+func newJSONProfileHandler(w io.Writer, opts *slog.HandlerOptions, profile jsonProfile) *jsonProfileHandler {
+	return &jsonProfileHandler{
+		baseHandler: newBaseHandler(opts),
+		mu:          new(sync.Mutex),
+		w:           w,
+		profile:     profile,
+	}
+}
+
+func (h *jsonProfileHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.baseHandler = h.withAttrs(attrs)
+	return &h2
+}
+
+func (h *jsonProfileHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.baseHandler = h.withGroup(name)
+	return &h2
+}
+
+func (h *jsonProfileHandler) Handle(ctx context.Context, r slog.Record) error {
+	record := &jsonRecord{
+		time:    r.Time,
+		level:   r.Level,
+		message: r.Message,
+		attrs:   attrMap(h.recordAttrs(r)),
+	}
+	if group, ok := httpRecordGroup(ctx); ok {
+		record.http = slices.Clone(h.groups)
+		if group != "" {
+			record.http = append(record.http, group)
+		}
+		if record.http == nil {
+			record.http = []string{}
+		}
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		record.source = &slog.Source{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		}
+	}
+	b, err := marshalJSONLine(h.profile(record))
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err = h.w.Write(b)
+	return err
+}
+
+// formatJSONProfile implements [logrus.Formatter] for a jsonProfile.
+// The caller is taken from the entry if reported by logrus,
+// or from the "caller" field set by this package.
+func formatJSONProfile(entry *logrus.Entry, profile jsonProfile) ([]byte, error) {
+	record := &jsonRecord{
+		time:    entry.Time,
+		level:   slogLevel(entry.Level),
+		message: entry.Message,
+		attrs:   make(map[string]any, len(entry.Data)),
+	}
+	for key, value := range entry.Data {
+		record.attrs[key] = logrusValue(value)
+	}
+	if entry.HasCaller() {
+		record.source = &slog.Source{
+			Function: entry.Caller.Function,
+			File:     entry.Caller.File,
+			Line:     entry.Caller.Line,
+		}
+	} else if source, ok := parseCaller(record.attrs["caller"]); ok {
+		record.source = source
+		delete(record.attrs, "caller")
+	}
+	return marshalJSONLine(profile(record))
+}
+
+func logrusValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[key] = logrusValue(value)
+		}
+		return m
+	case logrus.Fields:
+		return logrusValue(map[string]any(v))
+	default:
+		return slog.AnyValue(v).Resolve()
+	}
+}
+
+// parseCaller parses a "file:line" caller field.
+func parseCaller(v any) (*slog.Source, bool) {
+	value, ok := v.(slog.Value)
+	if !ok || value.Kind() != slog.KindString {
+		return nil, false
+	}
+	i := strings.LastIndexByte(value.String(), ':')
+	if i < 0 {
+		return nil, false
+	}
+	file, line := value.String()[:i], value.String()[i+1:]
+	n, err := strconv.Atoi(line)
+	if err != nil {
+		return nil, false
+	}
+	return &slog.Source{File: file, Line: n}, true
+}
+
+// slogLevel maps logrus levels to slog levels.
+// Fatal and Panic are mapped above ERROR.
+func slogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.PanicLevel:
+		return slog.LevelError + 8
+	case logrus.FatalLevel:
+		return slog.LevelError + 4
+	case logrus.ErrorLevel:
+		return slog.LevelError
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	default:
+		return slog.LevelDebug - 4
+	}
+}
+
+// logrusLevel maps slog levels to the logrus level
+// at or below them. Levels below DEBUG are TRACE.
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level >= slog.LevelError+8:
+		return logrus.PanicLevel
+	case level >= slog.LevelError+4:
+		return logrus.FatalLevel
+	case level >= slog.LevelError:
+		return logrus.ErrorLevel
+	case level >= slog.LevelWarn:
+		return logrus.WarnLevel
+	case level >= slog.LevelInfo:
+		return logrus.InfoLevel
+	case level >= slog.LevelDebug:
+		return logrus.DebugLevel
+	default:
+		return logrus.TraceLevel
+	}
+}
+
+// attrMap builds nested maps from the grouped attributes.
+// Leaves are [slog.Value].
+func attrMap(attrs []groupedAttr) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		parent := m
+		for _, group := range a.groups {
+			sub, ok := parent[group].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				parent[group] = sub
+			}
+			parent = sub
+		}
+		parent[a.attr.Key] = a.attr.Value
+	}
+	return m
+}
+
+// popValue removes and returns the value at path from m.
+// Empty parent maps are removed as well.
+func popValue(m map[string]any, path ...string) (slog.Value, bool) {
+	if len(path) == 1 {
+		v, ok := m[path[0]].(slog.Value)
+		if ok {
+			delete(m, path[0])
+		}
+		return v, ok
+	}
+	sub, ok := m[path[0]].(map[string]any)
+	if !ok {
+		return slog.Value{}, false
+	}
+	v, ok := popValue(sub, path[1:]...)
+	if len(sub) == 0 {
+		delete(m, path[0])
+	}
+	return v, ok
+}
+
+func marshalJSONLine(m map[string]any) ([]byte, error) {
+	b, err := json.Marshal(jsonValues(m))
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// jsonValues converts the [slog.Value] leaves of m
+// the same way as [slog.JSONHandler] does.
+func jsonValues(m map[string]any) map[string]any {
+	for key, v := range m {
+		switch v := v.(type) {
+		case map[string]any:
+			m[key] = jsonValues(v)
+		case slog.Value:
+			m[key] = jsonValue(v)
+		}
+	}
+	return m
+}
+
+func jsonValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindDuration:
+		return int64(v.Duration())
+	case slog.KindAny:
+		switch a := v.Any().(type) {
+		case nil:
+			return nil
+		case error:
+			if _, ok := a.(json.Marshaler); !ok {
+				return a.Error()
+			}
+		}
+		if _, err := json.Marshal(v.Any()); err != nil {
+			return fmt.Sprintf("%+v", v.Any())
+		}
+		return v.Any()
+	default:
+		return v.Any()
+	}
+}
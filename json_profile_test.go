@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replaceJSONField sets key in the JSON object line to value,
+// for fields which are not deterministic, like time.
+func replaceJSONField(t *testing.T, line, key string, value any) string {
+	t.Helper()
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(line), &m))
+	require.Contains(t, m, key)
+	m[key] = value
+	b, err := json.Marshal(m)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func Test_attrMap_popValue(t *testing.T) {
+	m := attrMap([]groupedAttr{
+		{attr: slog.Int("a", 1)},
+		{groups: []string{"g"}, attr: slog.Int("b", 2)},
+		{groups: []string{"g", "h"}, attr: slog.Int("c", 3)},
+	})
+	v, ok := popValue(m, "g", "h", "c")
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), v.Int64())
+	_, ok = popValue(m, "g", "h", "c")
+	assert.False(t, ok)
+	_, ok = popValue(m, "a", "b")
+	assert.False(t, ok)
+	assert.Equal(t, map[string]any{
+		"a": slog.IntValue(1),
+		"g": map[string]any{"b": slog.IntValue(2)},
+	}, m)
+}
+
+type unmarshalable struct {
+	C chan int
+}
+
+func Test_jsonValue(t *testing.T) {
+	assert.Equal(t, int64(time.Second), jsonValue(slog.DurationValue(time.Second)))
+	assert.Equal(t, "oops", jsonValue(slog.AnyValue(errors.New("oops"))))
+	assert.Equal(t, "{C:<nil>}", jsonValue(slog.AnyValue(unmarshalable{})))
+	assert.Equal(t, []int{1}, jsonValue(slog.AnyValue([]int{1})))
+	assert.Nil(t, jsonValue(slog.AnyValue(nil)))
+}
+
+func Test_parseCaller(t *testing.T) {
+	source, ok := parseCaller(slog.StringValue("C:/src/main.go:12"))
+	require.True(t, ok)
+	assert.Equal(t, &slog.Source{File: "C:/src/main.go", Line: 12}, source)
+	_, ok = parseCaller(slog.StringValue("main.go"))
+	assert.False(t, ok)
+	_, ok = parseCaller(slog.IntValue(1))
+	assert.False(t, ok)
+}
+
+func Test_slogLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug-4, slogLevel(logrus.TraceLevel))
+	assert.Equal(t, slog.LevelDebug, slogLevel(logrus.DebugLevel))
+	assert.Equal(t, slog.LevelInfo, slogLevel(logrus.InfoLevel))
+	assert.Equal(t, slog.LevelWarn, slogLevel(logrus.WarnLevel))
+	assert.Equal(t, slog.LevelError, slogLevel(logrus.ErrorLevel))
+}
@@ -0,0 +1,271 @@
+package logging
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogfmtFormatter formats logrus entries as logfmt.
+// Fields are sorted by key, nested maps are flattened
+// with dots as separator.
+type LogfmtFormatter struct {
+	// TimestampFormat is used for the time field,
+	// defaults to [time.RFC3339].
+	TimestampFormat string
+	// DisableTimestamp omits the time field.
+	DisableTimestamp bool
+	// FieldMap allows renaming the default keys.
+	FieldMap map[string]string
+}
+
+// Format implements [logrus.Formatter].
+func (f *LogfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	b := make([]byte, 0, 256)
+	if !f.DisableTimestamp {
+		format := f.TimestampFormat
+		if format == "" {
+			format = time.RFC3339
+		}
+		b = appendLogfmtString(b, f.key(logrus.FieldKeyTime), entry.Time.Format(format))
+	}
+	b = appendLogfmtString(b, f.key(logrus.FieldKeyLevel), entry.Level.String())
+	b = appendLogfmtString(b, f.key(logrus.FieldKeyMsg), entry.Message)
+	if entry.HasCaller() {
+		b = appendLogfmtString(b, f.key(logrus.FieldKeyFunc), entry.Caller.Function)
+		b = appendLogfmtString(b, f.key(logrus.FieldKeyFile), fmt.Sprintf("%s:%d", entry.Caller.File, entry.Caller.Line))
+	}
+	b = appendLogfmtMap(b, "", f.prefixFieldClashes(entry))
+	return append(b, '\n'), nil
+}
+
+// prefixFieldClashes returns the data of the entry, with fields
+// named like the default keys prefixed by "fields.", as logrus does.
+func (f *LogfmtFormatter) prefixFieldClashes(entry *logrus.Entry) map[string]any {
+	keys := []string{f.key(logrus.FieldKeyTime), f.key(logrus.FieldKeyLevel), f.key(logrus.FieldKeyMsg)}
+	if entry.HasCaller() {
+		keys = append(keys, f.key(logrus.FieldKeyFunc), f.key(logrus.FieldKeyFile))
+	}
+	var data map[string]any
+	for _, key := range keys {
+		v, ok := entry.Data[key]
+		if !ok {
+			continue
+		}
+		if data == nil {
+			data = maps.Clone(entry.Data)
+		}
+		delete(data, key)
+		data["fields."+key] = v
+	}
+	if data == nil {
+		return entry.Data
+	}
+	return data
+}
+
+func (f *LogfmtFormatter) key(key string) string {
+	if k, ok := f.FieldMap[key]; ok {
+		return k
+	}
+	return key
+}
+
+func appendLogfmtMap(b []byte, prefix string, m map[string]any) []byte {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case map[string]any:
+			b = appendLogfmtMap(b, prefix+key+".", v)
+		case logrus.Fields:
+			b = appendLogfmtMap(b, prefix+key+".", v)
+		default:
+			b = appendLogfmtAny(b, prefix+key, v)
+		}
+	}
+	return b
+}
+
+// NewLogfmtHandler creates a [slog.Handler] which writes records
+// as logfmt to w, using the given options.
+// If opts is nil, the default options are used.
+//
+// Unlike [slog.TextHandler], strings are quoted and escaped the same
+// way as JSON strings, which all logfmt parsers understand.
+// Groups are flattened with dots as separator.
+func NewLogfmtHandler(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	return &logfmtHandler{
+		baseHandler: newBaseHandler(opts),
+		mu:          new(sync.Mutex),
+		w:           w,
+	}
+}
+
+type logfmtHandler struct {
+	baseHandler
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (h *logfmtHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &logfmtHandler{baseHandler: h.withAttrs(attrs), mu: h.mu, w: h.w}
+}
+
+func (h *logfmtHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &logfmtHandler{baseHandler: h.withGroup(name), mu: h.mu, w: h.w}
+}
+
+func (h *logfmtHandler) Handle(_ context.Context, r slog.Record) error {
+	b := make([]byte, 0, 256)
+	if !r.Time.IsZero() {
+		if a, ok := h.builtinAttr(slog.Time(slog.TimeKey, r.Time)); ok {
+			b = appendLogfmtValue(b, a.Key, a.Value)
+		}
+	}
+	if a, ok := h.builtinAttr(slog.Any(slog.LevelKey, r.Level)); ok {
+		b = appendLogfmtValue(b, a.Key, a.Value)
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		if a, ok := h.builtinAttr(slog.String(slog.SourceKey, recordSource(r))); ok {
+			b = appendLogfmtValue(b, a.Key, a.Value)
+		}
+	}
+	if a, ok := h.builtinAttr(slog.String(slog.MessageKey, r.Message)); ok {
+		b = appendLogfmtValue(b, a.Key, a.Value)
+	}
+	for _, a := range h.recordAttrs(r) {
+		b = appendLogfmtValue(b, a.key("."), a.attr.Value)
+	}
+	b = append(b, '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(b)
+	return err
+}
+
+// recordSource returns "file:line" of the record's caller.
+func recordSource(r slog.Record) string {
+	frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	return frame.File + ":" + strconv.Itoa(frame.Line)
+}
+
+func appendLogfmtValue(b []byte, key string, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendLogfmtString(b, key, v.String())
+	case slog.KindTime:
+		return appendLogfmtString(b, key, v.Time().Format(time.RFC3339Nano))
+	case slog.KindAny:
+		return appendLogfmtAny(b, key, v.Any())
+	default:
+		// bool, numbers and durations never need quoting.
+		b = appendLogfmtKey(b, key)
+		return append(b, v.String()...)
+	}
+}
+
+func appendLogfmtAny(b []byte, key string, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return appendLogfmtString(b, key, "<nil>")
+	case slog.Level:
+		return appendLogfmtString(b, key, v.String())
+	case error:
+		return appendLogfmtString(b, key, v.Error())
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			return appendLogfmtString(b, key, "!ERROR:"+err.Error())
+		}
+		return appendLogfmtString(b, key, string(text))
+	case []byte:
+		return appendLogfmtString(b, key, string(v))
+	default:
+		return appendLogfmtString(b, key, fmt.Sprintf("%+v", v))
+	}
+}
+
+func appendLogfmtString(b []byte, key, value string) []byte {
+	b = appendLogfmtKey(b, key)
+	if !logfmtNeedsQuoting(value) {
+		return append(b, value...)
+	}
+	return appendEscapedString(b, value)
+}
+
+// appendLogfmtKey appends the key and "=", preceded by a space if
+// b is not empty. Characters not allowed in keys are replaced by "_".
+func appendLogfmtKey(b []byte, key string) []byte {
+	if len(b) > 0 {
+		b = append(b, ' ')
+	}
+	if key == "" {
+		key = "_"
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			r = '_'
+		}
+		b = utf8.AppendRune(b, r)
+	}
+	return append(b, '=')
+}
+
+func logfmtNeedsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendEscapedString appends s as quoted JSON string.
+// Invalid UTF-8 is replaced with the Unicode replacement character.
+func appendEscapedString(b []byte, s string) []byte {
+	b = append(b, '"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b = append(b, '\\', byte(r))
+		case r == '\n':
+			b = append(b, '\\', 'n')
+		case r == '\r':
+			b = append(b, '\\', 'r')
+		case r == '\t':
+			b = append(b, '\\', 't')
+		case r < ' ' || r == 0x7f:
+			b = append(b, '\\', 'u', '0', '0', hexDigits[r>>4], hexDigits[r&0xf])
+		default:
+			b = utf8.AppendRune(b, r)
+		}
+	}
+	return append(b, '"')
+}
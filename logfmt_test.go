@@ -0,0 +1,167 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseLogfmt parses lines written by the logfmt handler
+// into maps, with nested maps for dotted keys.
+func parseLogfmt(t *testing.T, out string) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		record := make(map[string]any)
+		for line != "" {
+			key, rest, ok := strings.Cut(line, "=")
+			require.True(t, ok, "missing = in %q", line)
+			var value string
+			if strings.HasPrefix(rest, `"`) {
+				quoted, err := strconv.QuotedPrefix(rest)
+				require.NoError(t, err)
+				value, err = strconv.Unquote(quoted)
+				require.NoError(t, err)
+				rest = rest[len(quoted):]
+			} else {
+				value, rest, _ = strings.Cut(rest, " ")
+				rest = " " + rest
+			}
+			line = strings.TrimPrefix(rest, " ")
+
+			m := record
+			path := strings.Split(key, ".")
+			for _, group := range path[:len(path)-1] {
+				sub, ok := m[group].(map[string]any)
+				if !ok {
+					sub = make(map[string]any)
+					m[group] = sub
+				}
+				m = sub
+			}
+			m[path[len(path)-1]] = value
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogfmtHandler_slogtest(t *testing.T) {
+	out := new(strings.Builder)
+	err := slogtest.TestHandler(NewLogfmtHandler(out, nil), func() []map[string]any {
+		return parseLogfmt(t, out.String())
+	})
+	require.NoError(t, err)
+}
+
+func TestLogfmtHandler(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs []any
+		want  string
+	}{
+		{
+			name:  "bare values",
+			attrs: []any{"str", "value", "int", 1, "bool", true, "duration", time.Second},
+			want:  `level=INFO msg="hello world" str=value int=1 bool=true duration=1s`,
+		},
+		{
+			name:  "quoted values",
+			attrs: []any{"empty", "", "space", "a b", "equal", "a=b", "quote", `a"b`, "newline", "a\nb", "control", "a\x01b"},
+			want:  `level=INFO msg="hello world" empty="" space="a b" equal="a=b" quote="a\"b" newline="a\nb" control="a\u0001b"`,
+		},
+		{
+			name:  "invalid keys",
+			attrs: []any{"a b", 1, "a=b", 2},
+			want:  `level=INFO msg="hello world" a_b=1 a_b=2`,
+		},
+		{
+			name: "groups",
+			attrs: []any{
+				slog.Group("request", "method", "GET", slog.Group("url", "path", "/")),
+				slog.Group("", "inlined", 1),
+				slog.Group("empty"),
+			},
+			want: `level=INFO msg="hello world" request.method=GET request.url.path=/ inlined=1`,
+		},
+		{
+			name:  "any",
+			attrs: []any{"err", errors.New("oops"), "nil", nil, "at", time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), "slice", []int{1, 2}},
+			want:  `level=INFO msg="hello world" err=oops nil=<nil> at=2026-10-17T12:00:00Z slice="[1 2]"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(strings.Builder)
+			logger := slog.New(NewLogfmtHandler(out, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey && len(groups) == 0 {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+			logger.Info("hello world", tt.attrs...)
+			assert.Equal(t, tt.want+"\n", out.String())
+		})
+	}
+}
+
+func TestLogfmtHandler_withGroup(t *testing.T) {
+	out := new(strings.Builder)
+	logger := slog.New(NewLogfmtHandler(out, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			if a.Key == "secret" {
+				return slog.String(a.Key, "***")
+			}
+			return a
+		},
+	}))
+	logger.WithGroup("a").With("b", 1).WithGroup("c").Debug("msg", "secret", "pw")
+	assert.Equal(t, "level=DEBUG msg=msg a.b=1 a.c.secret=***\n", out.String())
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	logger := logrus.New()
+	out := new(bytes.Buffer)
+	logger.SetOutput(out)
+	logger.SetFormatter(&LogfmtFormatter{
+		DisableTimestamp: true,
+		FieldMap:         map[string]string{logrus.FieldKeyMsg: "message"},
+	})
+	logger.WithFields(logrus.Fields{
+		"z":       "last",
+		"a":       "first value",
+		"request": map[string]any{"method": "GET"},
+		"err":     fmt.Errorf("oops"),
+	}).Warn("hello")
+	assert.Equal(t, `level=warning message=hello a="first value" err=oops request.method=GET z=last`+"\n", out.String())
+}
+
+func TestLogfmtFormatter_fieldClashes(t *testing.T) {
+	logger := logrus.New()
+	out := new(bytes.Buffer)
+	logger.SetOutput(out)
+	logger.SetFormatter(&LogfmtFormatter{
+		DisableTimestamp: true,
+		FieldMap:         map[string]string{logrus.FieldKeyMsg: "message"},
+	})
+	fields := logrus.Fields{"time": "now", "level": 1, "message": "field", "msg": "other"}
+	logger.WithFields(fields).Info("hello")
+	assert.Equal(t, `level=info message=hello fields.level=1 fields.message=field fields.time=now msg=other`+"\n", out.String())
+	assert.Len(t, fields, 4)
+}
@@ -0,0 +1,355 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// LokiConfig configures the [LokiHandler].
+type LokiConfig struct {
+	// URL of the push API,
+	// defaults to "http://localhost:3100/loki/api/v1/push".
+	URL string `json:"url"`
+	// Encoding of the push requests, either "json" (default)
+	// or "protobuf", which is snappy compressed.
+	Encoding string `json:"encoding"`
+	// TenantID is sent as X-Scope-OrgID header, if set.
+	TenantID string `json:"tenantID" yaml:"tenantID"`
+	// Headers are added to each request,
+	// for example for authentication.
+	Headers map[string]string `json:"headers"`
+	// Labels are added to all streams, like "job".
+	Labels map[string]string `json:"labels"`
+	// LabelKeys are the attributes promoted to stream labels,
+	// with groups joined by a dot. "level" is the record level.
+	// Defaults to "service", "level" and "instance".
+	// Keep the set small, as each combination of values
+	// creates a new stream.
+	LabelKeys []string `json:"labelKeys" yaml:"labelKeys"`
+	// LineFormat of the remaining attributes and the message,
+	// either "logfmt" (default) or "json".
+	LineFormat string `json:"lineFormat" yaml:"lineFormat"`
+	// Client is used to send requests,
+	// defaults to a client with a timeout of 10 seconds.
+	Client *http.Client `json:"-" yaml:"-"`
+
+	BatchOptions `yaml:",inline"`
+	RetryOptions `yaml:",inline"`
+}
+
+// LokiHandler is a [Sink] pushing records in batches to Grafana Loki.
+//
+// The attributes named by LabelKeys are removed from the record
+// and used as stream labels. The message and remaining attributes
+// form the log line. Requests failing with an error or a 429, 502,
+// 503 or 504 status code are retried as configured by the RetryOptions.
+// Memory is bounded by the MaxBuffered records of the BatchOptions.
+type LokiHandler struct {
+	baseHandler
+	exporter *lokiExporter
+}
+
+// NewLokiHandler creates a [LokiHandler] and starts
+// the background goroutine sending batches.
+// It returns an error for an unknown encoding or line format.
+// If opts is nil, the default options are used.
+func NewLokiHandler(config LokiConfig, opts *slog.HandlerOptions) (*LokiHandler, error) {
+	if config.URL == "" {
+		config.URL = "http://localhost:3100/loki/api/v1/push"
+	}
+	switch config.Encoding {
+	case "":
+		config.Encoding = "json"
+	case "json", "protobuf":
+	default:
+		return nil, fmt.Errorf("logging: loki encoding %q not supported", config.Encoding)
+	}
+	switch config.LineFormat {
+	case "":
+		config.LineFormat = "logfmt"
+	case "logfmt", "json":
+	default:
+		return nil, fmt.Errorf("logging: loki line format %q not supported", config.LineFormat)
+	}
+	if config.LabelKeys == nil {
+		config.LabelKeys = []string{"service", "level", "instance"}
+	}
+	if config.Client == nil {
+		config.Client = defaultSinkClient
+	}
+	config.RetryOptions = config.RetryOptions.withDefaults()
+	e := &lokiExporter{config: config}
+	e.batcher = newBatcher(config.BatchOptions, e.send, lokiSpoolCodec)
+	return &LokiHandler{
+		baseHandler: newBaseHandler(opts),
+		exporter:    e,
+	}, nil
+}
+
+func (h *LokiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &LokiHandler{baseHandler: h.withAttrs(attrs), exporter: h.exporter}
+}
+
+func (h *LokiHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &LokiHandler{baseHandler: h.withGroup(name), exporter: h.exporter}
+}
+
+func (h *LokiHandler) Handle(_ context.Context, r slog.Record) error {
+	config := &h.exporter.config
+	attrs := attrMap(h.recordAttrs(r))
+	labels := maps.Clone(config.Labels)
+	if labels == nil {
+		labels = make(map[string]string, len(config.LabelKeys))
+	}
+	levelLabel := false
+	for _, key := range config.LabelKeys {
+		if key == slog.LevelKey {
+			labels[key] = strings.ToLower(r.Level.String())
+			levelLabel = true
+			continue
+		}
+		if v, ok := popValue(attrs, strings.Split(key, ".")...); ok {
+			labels[lokiLabelName(key)] = v.String()
+		}
+	}
+	if !levelLabel {
+		if a, ok := h.builtinAttr(slog.Any(slog.LevelKey, r.Level)); ok {
+			attrs[a.Key] = slog.StringValue(a.Value.String())
+		}
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		if a, ok := h.builtinAttr(slog.String(slog.SourceKey, recordSource(r))); ok {
+			attrs[a.Key] = a.Value
+		}
+	}
+	var line []byte
+	msg, hasMsg := h.builtinAttr(slog.String(slog.MessageKey, r.Message))
+	if config.LineFormat == "json" {
+		if hasMsg {
+			attrs[msg.Key] = msg.Value
+		}
+		var err error
+		if line, err = json.Marshal(jsonValues(attrs)); err != nil {
+			return err
+		}
+	} else {
+		if hasMsg {
+			line = appendLogfmtValue(line, msg.Key, msg.Value)
+		}
+		line = appendLogfmtMap(line, "", attrs)
+	}
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return h.exporter.batcher.add(lokiEntry{
+		labels: labels,
+		time:   t,
+		line:   string(line),
+	})
+}
+
+// Flush sends all buffered records.
+func (h *LokiHandler) Flush(ctx context.Context) error {
+	return h.exporter.batcher.Flush(ctx)
+}
+
+// Close sends all buffered records and stops
+// the background goroutine.
+func (h *LokiHandler) Close(ctx context.Context) error {
+	return h.exporter.batcher.Close(ctx)
+}
+
+type lokiEntry struct {
+	labels map[string]string
+	time   time.Time
+	line   string
+}
+
+// lokiSpoolCodec spools the entries as JSON.
+var lokiSpoolCodec = &spoolCodec[lokiEntry]{
+	encode: func(e lokiEntry) ([]byte, error) {
+		return json.Marshal(lokiSpooledEntry{Labels: e.labels, Time: e.time, Line: e.line})
+	},
+	decode: func(b []byte) (lokiEntry, error) {
+		var e lokiSpooledEntry
+		err := json.Unmarshal(b, &e)
+		return lokiEntry{labels: e.Labels, time: e.Time, line: e.Line}, err
+	},
+}
+
+type lokiSpooledEntry struct {
+	Labels map[string]string `json:"labels"`
+	Time   time.Time         `json:"time"`
+	Line   string            `json:"line"`
+}
+
+type lokiStream struct {
+	labels  map[string]string
+	entries []lokiEntry
+}
+
+type lokiExporter struct {
+	config  LokiConfig
+	batcher *batcher[lokiEntry]
+}
+
+func (e *lokiExporter) send(ctx context.Context, entries []lokiEntry) error {
+	streams := lokiStreams(entries)
+	var (
+		body        []byte
+		contentType string
+	)
+	if e.config.Encoding == "protobuf" {
+		body = snappy.Encode(nil, lokiProtobuf(streams))
+		contentType = "application/x-protobuf"
+	} else {
+		var err error
+		if body, err = lokiJSON(streams); err != nil {
+			return permanentError{err}
+		}
+		contentType = "application/json"
+	}
+	err := e.config.retry(ctx, func() error {
+		req, err := http.NewRequestWithContext(WithoutClientLogging(ctx), http.MethodPost, e.config.URL, bytes.NewReader(body))
+		if err != nil {
+			return permanentError{err}
+		}
+		req.Header.Set("Content-Type", contentType)
+		if e.config.TenantID != "" {
+			req.Header.Set("X-Scope-OrgID", e.config.TenantID)
+		}
+		for key, value := range e.config.Headers {
+			req.Header.Set(key, value)
+		}
+		return doSinkRequest(e.config.Client, req)
+	})
+	if err != nil {
+		return e.batcher.sendError("loki", len(entries), "records", err)
+	}
+	return nil
+}
+
+// lokiStreams groups the entries by their labels,
+// keeping the order of the entries.
+// The streams are sorted by their labels.
+func lokiStreams(entries []lokiEntry) []lokiStream {
+	byLabels := make(map[string]*lokiStream)
+	for _, entry := range entries {
+		key := lokiLabels(entry.labels)
+		stream, ok := byLabels[key]
+		if !ok {
+			stream = &lokiStream{labels: entry.labels}
+			byLabels[key] = stream
+		}
+		stream.entries = append(stream.entries, entry)
+	}
+	streams := make([]lokiStream, 0, len(byLabels))
+	for _, key := range slices.Sorted(maps.Keys(byLabels)) {
+		streams = append(streams, *byLabels[key])
+	}
+	return streams
+}
+
+func lokiJSON(streams []lokiStream) ([]byte, error) {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	req := struct {
+		Streams []stream `json:"streams"`
+	}{
+		Streams: make([]stream, 0, len(streams)),
+	}
+	for _, s := range streams {
+		values := make([][2]string, 0, len(s.entries))
+		for _, entry := range s.entries {
+			values = append(values, [2]string{strconv.FormatInt(entry.time.UnixNano(), 10), entry.line})
+		}
+		req.Streams = append(req.Streams, stream{Stream: s.labels, Values: values})
+	}
+	return json.Marshal(req)
+}
+
+// lokiProtobuf encodes the logproto.PushRequest message:
+//
+//	message PushRequest { repeated StreamAdapter streams = 1; }
+//	message StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	message EntryAdapter { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func lokiProtobuf(streams []lokiStream) []byte {
+	var req []byte
+	for _, s := range streams {
+		var stream []byte
+		stream = protowire.AppendTag(stream, 1, protowire.BytesType)
+		stream = protowire.AppendString(stream, lokiLabels(s.labels))
+		for _, e := range s.entries {
+			var ts []byte
+			ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(e.time.Unix()))
+			ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(e.time.Nanosecond()))
+
+			var entry []byte
+			entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+			entry = protowire.AppendBytes(entry, ts)
+			entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+			entry = protowire.AppendString(entry, e.line)
+
+			stream = protowire.AppendTag(stream, 2, protowire.BytesType)
+			stream = protowire.AppendBytes(stream, entry)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, stream)
+	}
+	return req
+}
+
+// lokiLabels formats labels in the Prometheus
+// text format, like {job="api", level="info"}.
+func lokiLabels(labels map[string]string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, key := range slices.Sorted(maps.Keys(labels)) {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[key]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// lokiLabelName replaces characters which are not
+// allowed in label names with underscores.
+func lokiLabelName(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_',
+			c >= '0' && c <= '9' && i > 0:
+		default:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
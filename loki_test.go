@@ -0,0 +1,179 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+var lokiTestTime = time.Unix(1715000000, 123)
+
+func handleLokiTestRecords(t *testing.T, h *LokiHandler) {
+	t.Helper()
+	logger := slog.New(h).With("service", "api", "instance", "a")
+	for _, level := range []slog.Level{slog.LevelInfo, slog.LevelError, slog.LevelInfo} {
+		r := slog.NewRecord(lokiTestTime, level, "request served", 0)
+		r.AddAttrs(slog.Group("http", slog.String("path", "/")), slog.Int("status", 200))
+		require.NoError(t, logger.Handler().Handle(context.Background(), r))
+	}
+	require.NoError(t, h.Close(context.Background()))
+}
+
+func TestLokiHandler_json(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "tenant", r.Header.Get("X-Scope-OrgID"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	h, err := NewLokiHandler(LokiConfig{
+		URL:          ts.URL + "/loki/api/v1/push",
+		TenantID:     "tenant",
+		Labels:       map[string]string{"job": "zitadel"},
+		BatchOptions: BatchOptions{FlushInterval: time.Hour},
+	}, nil)
+	require.NoError(t, err)
+	handleLokiTestRecords(t, h)
+
+	assert.JSONEq(t, `{"streams": [
+		{
+			"stream": {"job": "zitadel", "service": "api", "instance": "a", "level": "error"},
+			"values": [["1715000000000000123", "msg=\"request served\" http.path=/ status=200"]]
+		},
+		{
+			"stream": {"job": "zitadel", "service": "api", "instance": "a", "level": "info"},
+			"values": [
+				["1715000000000000123", "msg=\"request served\" http.path=/ status=200"],
+				["1715000000000000123", "msg=\"request served\" http.path=/ status=200"]
+			]
+		}
+	]}`, string(<-bodies))
+}
+
+func TestLokiHandler_protobuf(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		bodies <- body
+	}))
+	defer ts.Close()
+
+	h, err := NewLokiHandler(LokiConfig{
+		URL:          ts.URL,
+		Encoding:     "protobuf",
+		LabelKeys:    []string{"service", "http.path"},
+		LineFormat:   "json",
+		BatchOptions: BatchOptions{FlushInterval: time.Hour},
+	}, nil)
+	require.NoError(t, err)
+	handleLokiTestRecords(t, h)
+
+	body, err := snappy.Decode(nil, <-bodies)
+	require.NoError(t, err)
+	streams := decodeProtobufFields(t, body)[1]
+	require.Len(t, streams, 1)
+	stream := decodeProtobufFields(t, streams[0])
+	assert.Equal(t, `{http_path="/", service="api"}`, string(stream[1][0]))
+	require.Len(t, stream[2], 3)
+	entry := decodeProtobufFields(t, stream[2][1])
+	timestamp := decodeProtobufFields(t, entry[1][0])
+	seconds, _ := protowire.ConsumeVarint(timestamp[1][0])
+	nanos, _ := protowire.ConsumeVarint(timestamp[2][0])
+	assert.Equal(t, lokiTestTime, time.Unix(int64(seconds), int64(nanos)))
+	assert.JSONEq(t, `{"instance":"a","level":"ERROR","msg":"request served","status":200}`, string(entry[2][0]))
+}
+
+// decodeProtobufFields returns the raw values by field number.
+// Varints are returned in their encoded form.
+func decodeProtobufFields(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	t.Helper()
+	fields := make(map[protowire.Number][][]byte)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		require.GreaterOrEqual(t, n, 0)
+		value := b[:n]
+		if typ == protowire.BytesType {
+			value, _ = protowire.ConsumeBytes(value)
+		}
+		fields[num] = append(fields[num], value)
+		b = b[n:]
+	}
+	return fields
+}
+
+func TestLokiHandler_retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		wantErr  bool
+		attempts int32
+	}{
+		{"retried", http.StatusServiceUnavailable, false, 3},
+		{"permanent", http.StatusBadRequest, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) < 3 {
+					w.WriteHeader(tt.status)
+				}
+			}))
+			defer ts.Close()
+
+			h, err := NewLokiHandler(LokiConfig{
+				URL:          ts.URL,
+				BatchOptions: BatchOptions{FlushInterval: time.Hour},
+				RetryOptions: RetryOptions{
+					Backoff: func(int) time.Duration { return time.Millisecond },
+				},
+			}, nil)
+			require.NoError(t, err)
+			slog.New(h).Info("hello")
+			err = h.Close(context.Background())
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "dropped 1 records")
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.attempts, attempts.Load())
+		})
+	}
+}
+
+func TestNewLokiHandler_errors(t *testing.T) {
+	for _, config := range []LokiConfig{
+		{Encoding: "xml"},
+		{LineFormat: "text"},
+	} {
+		_, err := NewLokiHandler(config, nil)
+		assert.Error(t, err)
+	}
+}
+
+func Test_lokiLabelName(t *testing.T) {
+	assert.Equal(t, "http_path", lokiLabelName("http.path"))
+	assert.Equal(t, "_st", lokiLabelName("1st"))
+	assert.Equal(t, "a_1", lokiLabelName("a-1"))
+}
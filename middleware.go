@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"io"
 	"net/http"
 	"time"
 
@@ -57,6 +58,50 @@ func WithLoggedWriter(wrap func(w http.ResponseWriter) LoggedWriter) MiddlewareO
 	}
 }
 
+// WithRequestStarted prints a line with the request attributes
+// at the passed level, before the next handler is called.
+// This leaves a trace of requests which never complete.
+func WithRequestStarted(level slog.Level) MiddlewareOption {
+	return func(m *middleware) {
+		m.logStart = true
+		m.startLevel = level
+	}
+}
+
+// WithStillRunning prints a WARN line if the next handler
+// did not return after the passed threshold.
+// The line is printed at most once per request.
+func WithStillRunning(threshold time.Duration) MiddlewareOption {
+	return func(m *middleware) {
+		m.stillRunning = threshold
+	}
+}
+
+// WithSlowThreshold prints the request served line at WARN level
+// with a slow=true attribute, when the request took longer than d.
+func WithSlowThreshold(d time.Duration) MiddlewareOption {
+	return func(m *middleware) {
+		m.slowThreshold = constantThreshold(d)
+	}
+}
+
+// WithSlowThresholdFunc is like [WithSlowThreshold],
+// but obtains the threshold for each request.
+// This allows different thresholds per route, see [PathSlowThresholds].
+func WithSlowThresholdFunc(threshold func(*http.Request) time.Duration) MiddlewareOption {
+	return func(m *middleware) {
+		m.slowThreshold = threshold
+	}
+}
+
+// WithAccessLog writes a line in the passed format to out for every
+// request, alongside the request served line. See [AccessLog].
+func WithAccessLog(out io.Writer, format AccessLogFormat) MiddlewareOption {
+	return func(m *middleware) {
+		m.accessLog = newAccessLogger(out, format)
+	}
+}
+
 // Middleware enables request logging and sets a logger
 // to the request context.
 // Use [FromContext] to obtain the logger anywhere in the request liftime.
@@ -90,6 +135,13 @@ type middleware struct {
 	duration   func(time.Time) time.Duration
 	reqAttr    func(*http.Request) slog.Attr
 	wrapWriter func(http.ResponseWriter) LoggedWriter
+
+	logStart     bool
+	startLevel   slog.Level
+	stillRunning time.Duration
+
+	slowThreshold func(*http.Request) time.Duration
+	accessLog     *accessLogger
 }
 
 func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -100,18 +152,41 @@ func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		logger = logger.With(slog.Group(m.group, m.nextID()))
 	}
 	r = r.WithContext(ToContext(r.Context(), logger))
+	logCtx := withHTTPRecord(r.Context(), m.group)
+
+	if m.logStart {
+		logger.Log(logCtx, m.startLevel, "request started")
+	}
+	if m.stillRunning > 0 {
+		timer := time.AfterFunc(m.stillRunning, func() {
+			logger.WarnContext(logCtx, "request still running",
+				slog.Group(m.group, slog.Duration("duration", m.duration(start))),
+			)
+		})
+		defer timer.Stop()
+	}
 
 	lw := m.wrapWriter(w)
 	m.next.ServeHTTP(lw, r)
-	logger = logger.With(slog.Group(m.group,
-		slog.Duration("duration", m.duration(start)),
+	if m.accessLog != nil {
+		m.accessLog.log(r, start, lw)
+	}
+	duration := m.duration(start)
+	attrs := []any{
+		slog.Duration("duration", duration),
 		lw.Attr(),
-	))
+	}
+	level := slog.LevelInfo
+	if isSlow(m.slowThreshold, r, duration) {
+		attrs = append(attrs, slog.Bool("slow", true))
+		level = slog.LevelWarn
+	}
+	logger = logger.With(slog.Group(m.group, attrs...))
 	if err := lw.Err(); err != nil {
-		logger.WarnContext(r.Context(), "write response", "error", err)
+		logger.WarnContext(logCtx, "write response", "error", err)
 		return
 	}
-	logger.InfoContext(r.Context(), "request served")
+	logger.Log(logCtx, level, "request served")
 }
 
 type loggedWriter struct {
@@ -153,3 +228,11 @@ func (lw *loggedWriter) Attr() slog.Attr {
 func (lw *loggedWriter) Err() error {
 	return lw.err
 }
+
+func (lw *loggedWriter) Status() int {
+	return lw.statusCode
+}
+
+func (lw *loggedWriter) Written() int {
+	return lw.written
+}
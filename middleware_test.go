@@ -6,12 +6,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"log/slog"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func newTestLogger() (out *strings.Builder, logger *slog.Logger) {
@@ -43,9 +45,10 @@ func newTestWriter(err error) *testWriter {
 
 func TestMiddleware(t *testing.T) {
 	tests := []struct {
-		name string
-		err  error
-		want string
+		name          string
+		err           error
+		slowThreshold time.Duration
+		want          string
 	}{
 		{
 			name: "ok",
@@ -65,6 +68,45 @@ func TestMiddleware(t *testing.T) {
 				}
 			}`,
 		},
+		{
+			name:          "slow",
+			slowThreshold: time.Millisecond,
+			want: `{
+				"level":"WARN",
+				"time": "not",
+				"msg":"request served",
+				"id":"id1",
+				"duration":1000000000,
+				"slow":true,
+				"request":{
+					"method":"GET",
+					"url":"https://example.com/path/"
+				},
+				"response":{
+					"status":200,
+					"written":13
+				}
+			}`,
+		},
+		{
+			name:          "not slow",
+			slowThreshold: time.Minute,
+			want: `{
+				"level":"INFO",
+				"time": "not",
+				"msg":"request served",
+				"id":"id1",
+				"duration":1000000000,
+				"request":{
+					"method":"GET",
+					"url":"https://example.com/path/"
+				},
+				"response":{
+					"status":200,
+					"written":13
+				}
+			}`,
+		},
 		{
 			name: "error",
 			err:  io.ErrClosedPipe,
@@ -100,6 +142,7 @@ func TestMiddleware(t *testing.T) {
 				}),
 				WithRequestAttr(requestToAttr),
 				WithLoggedWriter(newLoggedWriter),
+				WithSlowThreshold(tt.slowThreshold),
 			)
 
 			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -115,3 +158,83 @@ func TestMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestMiddleware_requestStarted(t *testing.T) {
+	logOut, logger := newTestLogger()
+	mw := Middleware(
+		WithLogger(logger),
+		WithDurationFunc(func(time.Time) time.Duration {
+			return time.Second
+		}),
+		WithRequestStarted(slog.LevelDebug),
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Hello, World!")
+	})
+	r := httptest.NewRequest("GET", "https://example.com/path/", nil)
+	mw(next).ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(logOut.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{
+		"level":"DEBUG",
+		"time": "not",
+		"msg":"request started",
+		"request":{
+			"method":"GET",
+			"url":"https://example.com/path/"
+		}
+	}`, lines[0])
+	assert.Contains(t, lines[1], `"msg":"request served"`)
+}
+
+type syncWriter struct {
+	mu  sync.Mutex
+	out strings.Builder
+}
+
+func (w *syncWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.out.Write(b)
+}
+
+func (w *syncWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.out.String()
+}
+
+func TestMiddleware_stillRunning(t *testing.T) {
+	logOut := new(syncWriter)
+	logger := slog.New(slog.NewJSONHandler(logOut, nil).WithAttrs([]slog.Attr{slog.String("time", "not")}))
+	mw := Middleware(
+		WithLogger(logger),
+		WithDurationFunc(func(time.Time) time.Duration {
+			return time.Second
+		}),
+		WithStillRunning(time.Millisecond),
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Eventually(t, func() bool {
+			return strings.Contains(logOut.String(), "request still running")
+		}, time.Second, time.Millisecond)
+		fmt.Fprint(w, "Hello, World!")
+	})
+	r := httptest.NewRequest("GET", "https://example.com/path/", nil)
+	mw(next).ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(logOut.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{
+		"level":"WARN",
+		"time": "not",
+		"msg":"request still running",
+		"duration":1000000000,
+		"request":{
+			"method":"GET",
+			"url":"https://example.com/path/"
+		}
+	}`, lines[0])
+	assert.Contains(t, lines[1], `"msg":"request served"`)
+}
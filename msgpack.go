@@ -0,0 +1,234 @@
+package logging
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"time"
+)
+
+// The MessagePack encoding is limited to the
+// types needed by the fluent forward protocol.
+
+func appendMsgpackNil(b []byte) []byte {
+	return append(b, 0xc0)
+}
+
+func appendMsgpackBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, 0xc3)
+	}
+	return append(b, 0xc2)
+}
+
+func appendMsgpackInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendMsgpackUint(b, uint64(v))
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v))
+	}
+}
+
+func appendMsgpackUint(b []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), v)
+	}
+}
+
+func appendMsgpackFloat(b []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(v))
+}
+
+func appendMsgpackString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n <= 31:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func appendMsgpackArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+	}
+}
+
+func appendMsgpackMapHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+	}
+}
+
+// appendMsgpackEventTime appends t as the fluent EventTime
+// extension type 0, with seconds and nanoseconds.
+func appendMsgpackEventTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = binary.BigEndian.AppendUint32(b, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+}
+
+// appendMsgpackMap appends a map created by attrMap,
+// with sorted keys.
+func appendMsgpackMap(b []byte, m map[string]any) []byte {
+	b = appendMsgpackMapHeader(b, len(m))
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		b = appendMsgpackString(b, key)
+		switch v := m[key].(type) {
+		case map[string]any:
+			b = appendMsgpackMap(b, v)
+		case slog.Value:
+			b = appendMsgpackValue(b, v)
+		default:
+			b = appendMsgpackNil(b)
+		}
+	}
+	return b
+}
+
+// appendMsgpackValue appends v like the [slog.JSONHandler] would
+// encode it: durations as nanoseconds, times as RFC 3339 strings
+// and other values as strings or JSON strings.
+func appendMsgpackValue(b []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindBool:
+		return appendMsgpackBool(b, v.Bool())
+	case slog.KindInt64:
+		return appendMsgpackInt(b, v.Int64())
+	case slog.KindDuration:
+		return appendMsgpackInt(b, int64(v.Duration()))
+	case slog.KindUint64:
+		return appendMsgpackUint(b, v.Uint64())
+	case slog.KindFloat64:
+		return appendMsgpackFloat(b, v.Float64())
+	case slog.KindTime:
+		return appendMsgpackString(b, v.Time().Format(time.RFC3339Nano))
+	case slog.KindString:
+		return appendMsgpackString(b, v.String())
+	default:
+		if v.Any() == nil {
+			return appendMsgpackNil(b)
+		}
+		return appendMsgpackString(b, jsonString(v))
+	}
+}
+
+// Limits of the maps and strings read, which are only small
+// responses like the ack of the forward protocol. Larger lengths
+// are rejected instead of allocating memory for them.
+const (
+	maxReadMsgpackMapLen    = 16
+	maxReadMsgpackStringLen = 256
+)
+
+// readMsgpackStringMap reads a map with string keys and values,
+// like the ack response of the forward protocol.
+func readMsgpackStringMap(r io.Reader) (map[string]string, error) {
+	n, err := readMsgpackHeader(r, 0x80, 0x8f, 0xde, 0xdf)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxReadMsgpackMapLen {
+		return nil, fmt.Errorf("msgpack map of %d entries too long", n)
+	}
+	m := make(map[string]string, n)
+	for range n {
+		key, err := readMsgpackString(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readMsgpackString(r)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+func readMsgpackString(r io.Reader) (string, error) {
+	n, err := readMsgpackHeader(r, 0xa0, 0xbf, 0xda, 0xdb, 0xd9)
+	if err != nil {
+		return "", err
+	}
+	if n > maxReadMsgpackStringLen {
+		return "", fmt.Errorf("msgpack string of %d bytes too long", n)
+	}
+	b := make([]byte, n)
+	if _, err = io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readMsgpackHeader reads the length of a fix type in the range
+// from fixMin to fixMax, or with a 16 or 32 bit length.
+// An optional 8 bit length type is accepted for strings.
+func readMsgpackHeader(r io.Reader, fixMin, fixMax, type16, type32 byte, type8 ...byte) (int, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:1]); err != nil {
+		return 0, err
+	}
+	var size int
+	switch t := b[0]; {
+	case t >= fixMin && t <= fixMax:
+		return int(t - fixMin), nil
+	case len(type8) > 0 && t == type8[0]:
+		size = 1
+	case t == type16:
+		size = 2
+	case t == type32:
+		size = 4
+	default:
+		return 0, fmt.Errorf("unsupported msgpack type 0x%02x", t)
+	}
+	if _, err := io.ReadFull(r, b[:size]); err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b[:2])), nil
+	default:
+		return int(binary.BigEndian.Uint32(b[:4])), nil
+	}
+}
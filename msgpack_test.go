@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeMsgpack decodes the types produced by the encoder of this package.
+// EventTime is decoded as [time.Time].
+func decodeMsgpack(r *bufio.Reader) (any, error) {
+	t, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	read := func(n int) []byte {
+		b := make([]byte, n)
+		_, err = io.ReadFull(r, b)
+		return b
+	}
+	switch {
+	case t <= 0x7f:
+		return int64(t), nil
+	case t >= 0xe0:
+		return int64(int8(t)), nil
+	case t >= 0xa0 && t <= 0xbf:
+		return string(read(int(t - 0xa0))), err
+	case t >= 0x90 && t <= 0x9f:
+		return decodeMsgpackArray(r, int(t-0x90))
+	case t >= 0x80 && t <= 0x8f:
+		return decodeMsgpackMap(r, int(t-0x80))
+	}
+	switch t {
+	case 0xc0:
+		return nil, nil
+	case 0xc2, 0xc3:
+		return t == 0xc3, nil
+	case 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(read(8))), err
+	case 0xcc:
+		return int64(read(1)[0]), err
+	case 0xcd:
+		return int64(binary.BigEndian.Uint16(read(2))), err
+	case 0xce:
+		return int64(binary.BigEndian.Uint32(read(4))), err
+	case 0xcf:
+		return int64(binary.BigEndian.Uint64(read(8))), err
+	case 0xd0:
+		return int64(int8(read(1)[0])), err
+	case 0xd1:
+		return int64(int16(binary.BigEndian.Uint16(read(2)))), err
+	case 0xd2:
+		return int64(int32(binary.BigEndian.Uint32(read(4)))), err
+	case 0xd3:
+		return int64(binary.BigEndian.Uint64(read(8))), err
+	case 0xd7:
+		b := read(9)
+		return time.Unix(int64(binary.BigEndian.Uint32(b[1:])), int64(binary.BigEndian.Uint32(b[5:]))).UTC(), err
+	case 0xd9:
+		return string(read(int(read(1)[0]))), err
+	case 0xda:
+		return string(read(int(binary.BigEndian.Uint16(read(2))))), err
+	case 0xdb:
+		return string(read(int(binary.BigEndian.Uint32(read(4))))), err
+	case 0xdc:
+		return decodeMsgpackArray(r, int(binary.BigEndian.Uint16(read(2))))
+	case 0xde:
+		return decodeMsgpackMap(r, int(binary.BigEndian.Uint16(read(2))))
+	}
+	return nil, fmt.Errorf("unexpected type 0x%02x", t)
+}
+
+func decodeMsgpackArray(r *bufio.Reader, n int) (any, error) {
+	a := make([]any, n)
+	for i := range a {
+		var err error
+		if a[i], err = decodeMsgpack(r); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func decodeMsgpackMap(r *bufio.Reader, n int) (any, error) {
+	m := make(map[string]any, n)
+	for range n {
+		key, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		if m[key.(string)], err = decodeMsgpack(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func Test_appendMsgpack(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	tests := []struct {
+		value slog.Value
+		want  any
+	}{
+		{slog.Int64Value(5), int64(5)},
+		{slog.Int64Value(-5), int64(-5)},
+		{slog.Int64Value(-100), int64(-100)},
+		{slog.Int64Value(-1000), int64(-1000)},
+		{slog.Int64Value(-100000), int64(-100000)},
+		{slog.Int64Value(math.MinInt64), int64(math.MinInt64)},
+		{slog.Int64Value(200), int64(200)},
+		{slog.Int64Value(60000), int64(60000)},
+		{slog.Int64Value(1 << 40), int64(1 << 40)},
+		{slog.Uint64Value(1 << 20), int64(1 << 20)},
+		{slog.Float64Value(0.5), 0.5},
+		{slog.BoolValue(true), true},
+		{slog.DurationValue(time.Second), int64(time.Second)},
+		{slog.StringValue("short"), "short"},
+		{slog.StringValue(strings.Repeat("x", 40)), strings.Repeat("x", 40)},
+		{slog.StringValue(long), long},
+		{slog.TimeValue(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)), "2024-01-02T03:04:05Z"},
+		{slog.AnyValue(map[string]int{"a": 1}), `{"a":1}`},
+		{slog.AnyValue(nil), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value.String(), func(t *testing.T) {
+			got, err := decodeMsgpack(bufio.NewReader(bytes.NewReader(appendMsgpackValue(nil, tt.value))))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_appendMsgpackMap(t *testing.T) {
+	m := map[string]any{
+		"a": slog.IntValue(1),
+		"g": map[string]any{"b": slog.StringValue("c")},
+	}
+	for i := range 20 {
+		m[fmt.Sprint("k", i)] = slog.IntValue(i)
+	}
+	got, err := decodeMsgpack(bufio.NewReader(bytes.NewReader(appendMsgpackMap(nil, m))))
+	require.NoError(t, err)
+	assert.Len(t, got, 22)
+	assert.Equal(t, map[string]any{"b": "c"}, got.(map[string]any)["g"])
+}
+
+func Test_readMsgpackStringMap(t *testing.T) {
+	b := appendMsgpackMapHeader(nil, 1)
+	b = appendMsgpackString(b, "ack")
+	b = appendMsgpackString(b, strings.Repeat("x", 40))
+	got, err := readMsgpackStringMap(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ack": strings.Repeat("x", 40)}, got)
+
+	_, err = readMsgpackStringMap(bytes.NewReader(appendMsgpackArrayHeader(nil, 1)))
+	assert.ErrorContains(t, err, "unsupported msgpack type 0x91")
+
+	_, err = readMsgpackStringMap(bytes.NewReader([]byte{0xdf, 0xff, 0xff, 0xff, 0xff}))
+	assert.EqualError(t, err, "msgpack map of 4294967295 entries too long")
+	b = appendMsgpackMapHeader(nil, 1)
+	b = appendMsgpackString(b, "ack")
+	b = append(b, 0xdb, 0xff, 0xff, 0xff, 0xff)
+	_, err = readMsgpackStringMap(bytes.NewReader(b))
+	assert.EqualError(t, err, "msgpack string of 4294967295 bytes too long")
+}
@@ -0,0 +1,184 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+)
+
+// MultiHandler passes each record to all of its handlers,
+// which are enabled for the level of the record.
+// This allows sending records to several outputs, like stderr
+// and a [Sink], each with its own level and format.
+// Use [FilterHandler] to select the records of a handler.
+//
+// Flush and Close are passed to the handlers implementing them,
+// so a MultiHandler of sinks can be used as a [Sink].
+type MultiHandler struct {
+	handlers []slog.Handler
+}
+
+// NewMultiHandler creates a [MultiHandler] for the handlers.
+func NewMultiHandler(handlers ...slog.Handler) *MultiHandler {
+	return &MultiHandler{handlers: slices.Clone(handlers)}
+}
+
+func (h *MultiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &MultiHandler{handlers: handlers}
+}
+
+func (h *MultiHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &MultiHandler{handlers: handlers}
+}
+
+// Handle passes a clone of the record to each enabled handler.
+// The errors of the handlers are joined.
+func (h *MultiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush flushes all handlers.
+func (h *MultiHandler) Flush(ctx context.Context) error {
+	errs := make([]error, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		errs = append(errs, flushHandler(ctx, handler))
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes all handlers.
+func (h *MultiHandler) Close(ctx context.Context) error {
+	errs := make([]error, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		errs = append(errs, closeHandler(ctx, handler))
+	}
+	return errors.Join(errs...)
+}
+
+// FilterHandler passes the records at or above its level,
+// which are accepted by its filter, to the wrapped handler.
+//
+// The record passed to the filter contains the attributes added
+// with WithAttrs and WithGroup, as if they were part of the record.
+// The wrapped handler receives the original record.
+type FilterHandler struct {
+	handler slog.Handler
+	level   slog.Leveler
+	filter  func(ctx context.Context, r slog.Record) bool
+	// attrs are nested in the groups they were added in.
+	attrs  []slog.Attr
+	groups []string
+}
+
+// NewFilterHandler wraps handler. If level is nil, only the level
+// of the handler applies. If filter is nil, all records are accepted.
+func NewFilterHandler(handler slog.Handler, level slog.Leveler, filter func(ctx context.Context, r slog.Record) bool) *FilterHandler {
+	return &FilterHandler{
+		handler: handler,
+		level:   level,
+		filter:  filter,
+	}
+}
+
+func (h *FilterHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.level != nil && level < h.level.Level() {
+		return false
+	}
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *FilterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	c := *h
+	c.handler = h.handler.WithAttrs(attrs)
+	if h.filter != nil {
+		c.attrs = append(slices.Clip(h.attrs), nestAttrs(h.groups, attrs)...)
+	}
+	return &c
+}
+
+func (h *FilterHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.handler = h.handler.WithGroup(name)
+	c.groups = append(slices.Clip(h.groups), name)
+	return &c
+}
+
+func (h *FilterHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.level != nil && r.Level < h.level.Level() {
+		return nil
+	}
+	if h.filter != nil && !h.filter(ctx, h.filterRecord(r)) {
+		return nil
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+// filterRecord adds the attributes of the handler to the record.
+func (h *FilterHandler) filterRecord(r slog.Record) slog.Record {
+	if len(h.attrs) == 0 && len(h.groups) == 0 {
+		return r
+	}
+	fr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	fr.AddAttrs(h.attrs...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	fr.AddAttrs(nestAttrs(h.groups, attrs)...)
+	return fr
+}
+
+// nestAttrs wraps the attributes in the groups.
+func nestAttrs(groups []string, attrs []slog.Attr) []slog.Attr {
+	for i := len(groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
+
+// Flush flushes the wrapped handler.
+func (h *FilterHandler) Flush(ctx context.Context) error {
+	return flushHandler(ctx, h.handler)
+}
+
+// Close closes the wrapped handler.
+func (h *FilterHandler) Close(ctx context.Context) error {
+	return closeHandler(ctx, h.handler)
+}
@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiHandler(t *testing.T) {
+	var text, json bytes.Buffer
+	opts := &slog.HandlerOptions{ReplaceAttr: removeTestTime}
+	sender := new(testSender)
+	sink := &testSink{b: newBatcher(BatchOptions{FlushInterval: time.Hour}, sender.send, nil)}
+	h := NewMultiHandler(
+		slog.NewTextHandler(&text, opts),
+		slog.NewJSONHandler(&json, &slog.HandlerOptions{Level: slog.LevelError, ReplaceAttr: removeTestTime}),
+		NewFilterHandler(sink, slog.LevelWarn, nil),
+	)
+	assert.True(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+
+	logger := slog.New(h).With("id", 1).WithGroup("g")
+	logger.Debug("debug")
+	logger.Info("info", "a", 1)
+	logger.Warn("warn")
+	logger.Error("error")
+
+	assert.Equal(t, "level=INFO msg=info id=1 g.a=1\nlevel=WARN msg=warn id=1\nlevel=ERROR msg=error id=1\n", text.String())
+	assert.Equal(t, `{"level":"ERROR","msg":"error","id":1}`+"\n", json.String())
+	require.NoError(t, h.Flush(context.Background()))
+	assert.Equal(t, [][]int{{1, 2}}, sender.get())
+	require.NoError(t, h.Close(context.Background()))
+	assert.True(t, sink.closed)
+}
+
+func TestMultiHandler_errors(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewMultiHandler(
+		&errorHandler{slog.NewTextHandler(&buf, nil)},
+		&errorHandler{slog.NewTextHandler(&buf, nil)},
+	)
+	err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "fail", 0))
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, "failed\nfailed", err.Error())
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("msg=fail")))
+}
+
+func TestFilterHandler(t *testing.T) {
+	var buf bytes.Buffer
+	var filtered []slog.Record
+	h := NewFilterHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: removeTestTime}), slog.LevelInfo,
+		func(_ context.Context, r slog.Record) bool {
+			filtered = append(filtered, r)
+			return OutputFilter{Exclude: map[string]string{"req.audit": "true"}}.match(context.Background(), r)
+		},
+	)
+	assert.False(t, h.Enabled(context.Background(), slog.LevelDebug))
+	logger := slog.New(h).WithGroup("req").With("id", 1)
+	logger.Info("audited", "audit", true)
+	logger.Info("passed", "audit", false)
+	assert.Equal(t, "level=INFO msg=passed req.id=1 req.audit=false\n", buf.String())
+
+	require.Len(t, filtered, 2)
+	var attrs []string
+	filtered[0].Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a.String())
+		return true
+	})
+	assert.Equal(t, []string{"req=[id=1]", "req=[audit=true]"}, attrs)
+
+	require.NoError(t, h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelDebug, "debug", 0)))
+	assert.Len(t, filtered, 2)
+}
+
+func TestOutputFilter_match(t *testing.T) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)
+	r.AddAttrs(
+		slog.String("component", "auth"),
+		slog.Group("http", slog.Int("status", 500)),
+	)
+	tests := []struct {
+		name   string
+		filter OutputFilter
+		want   bool
+	}{
+		{"empty", OutputFilter{}, true},
+		{"include", OutputFilter{Include: map[string]string{"component": "auth", "http.status": "500"}}, true},
+		{"include other value", OutputFilter{Include: map[string]string{"component": "db"}}, false},
+		{"include missing", OutputFilter{Include: map[string]string{"user": ""}}, false},
+		{"exclude", OutputFilter{Exclude: map[string]string{"http.status": "500"}}, false},
+		{"exclude other value", OutputFilter{Exclude: map[string]string{"component": "db"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.match(context.Background(), r))
+		})
+	}
+}
+
+func removeTestTime(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey && len(groups) == 0 {
+		return slog.Attr{}
+	}
+	return a
+}
@@ -0,0 +1,163 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// sinkConn is the connection of a sink sending records over
+// the network. It is established with the first write and
+// re-established once when a write fails.
+// It is not safe for concurrent use, sinks only use it
+// from the send function of their batcher and from Close.
+type sinkConn struct {
+	dial    func(ctx context.Context) (net.Conn, error)
+	timeout time.Duration
+	// frame optionally prepares a message for
+	// writing to the connection.
+	frame func(conn net.Conn, msg []byte) []byte
+
+	conn net.Conn
+}
+
+// newSinkConn returns a sinkConn dialing address with network.
+// The "tls" network uses TCP with tlsConfig.
+func newSinkConn(network, address string, tlsConfig *tls.Config, timeout time.Duration) *sinkConn {
+	dialer := &net.Dialer{Timeout: timeout}
+	dial := func(ctx context.Context) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	if network == "tls" {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: tlsConfig}
+		dial = func(ctx context.Context) (net.Conn, error) {
+			return tlsDialer.DialContext(ctx, "tcp", address)
+		}
+	}
+	return &sinkConn{dial: dial, timeout: timeout}
+}
+
+func (c *sinkConn) write(ctx context.Context, msg []byte) (err error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		if c.conn == nil {
+			if c.conn, err = c.dial(ctx); err != nil {
+				return err
+			}
+		}
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(c.timeout)
+		}
+		_ = c.conn.SetWriteDeadline(deadline)
+		b := msg
+		if c.frame != nil {
+			b = c.frame(c.conn, msg)
+		}
+		if _, err = c.conn.Write(b); err == nil {
+			return nil
+		}
+		c.close()
+	}
+	return err
+}
+
+func (c *sinkConn) close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// defaultSinkClient is used by sinks sending requests over HTTP,
+// if no client is configured. Unlike [http.DefaultClient] it has
+// a timeout, so an unresponsive destination does not block
+// the background flush of the batcher forever.
+var defaultSinkClient = &http.Client{Timeout: 10 * time.Second}
+
+// doSinkRequest sends the request and returns an error
+// for responses without a 2xx status code.
+// The error is a [permanentError] if the status code
+// indicates that retrying will not succeed.
+func doSinkRequest(client *http.Client, req *http.Request) error {
+	return doSinkJSONRequest(client, req, nil)
+}
+
+// doSinkJSONRequest is like doSinkRequest and decodes
+// the response body into v, unless v is nil.
+func doSinkJSONRequest(client *http.Client, req *http.Request, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err = fmt.Errorf("logging: %s %s: %s: %s", req.Method, req.URL, resp.Status, bytes.TrimSpace(msg))
+		if !retryableStatus(resp.StatusCode) {
+			return permanentError{err}
+		}
+		return err
+	}
+	if v != nil {
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// RetryOptions configure how sinks retry sending a batch.
+type RetryOptions struct {
+	// MaxAttempts to send a batch, defaults to 3.
+	MaxAttempts int `json:"maxAttempts" yaml:"maxAttempts"`
+	// Backoff returns the time to wait after a failed attempt,
+	// defaults to an [ExponentialBackoff] from 100ms to 10s.
+	Backoff func(attempt int) time.Duration `json:"-" yaml:"-"`
+}
+
+func (o RetryOptions) withDefaults() RetryOptions {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.Backoff == nil {
+		o.Backoff = ExponentialBackoff(100*time.Millisecond, 10*time.Second)
+	}
+	return o
+}
+
+// retry calls send until it succeeds, returns a [permanentError]
+// or MaxAttempts is reached, waiting for Backoff between the attempts.
+// It returns the last error.
+func (o RetryOptions) retry(ctx context.Context, send func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := send()
+		if err == nil || attempt >= o.MaxAttempts || errors.As(err, new(permanentError)) {
+			return err
+		}
+		timer := time.NewTimer(o.Backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+// permanentError is an error which is not
+// resolved by sending again.
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
@@ -0,0 +1,276 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// OTLPConfig configures the [OTLPHandler].
+type OTLPConfig struct {
+	// Endpoint is the URL logs are posted to,
+	// defaults to "http://localhost:4318/v1/logs".
+	Endpoint string `json:"endpoint"`
+	// Headers are added to each request,
+	// for example for authentication.
+	Headers map[string]string `json:"headers"`
+	// Resource are the attributes of the resource producing the logs,
+	// like "service.name".
+	Resource map[string]string `json:"resource"`
+	// Client is used to send requests,
+	// defaults to a client with a timeout of 10 seconds.
+	Client *http.Client `json:"-" yaml:"-"`
+
+	BatchOptions `yaml:",inline"`
+}
+
+// OTLPHandler is a [Sink] which converts records into the
+// OpenTelemetry log data model and sends them in batches
+// using OTLP/HTTP with JSON encoding.
+//
+// "trace_id" and "span_id" attributes are used as the
+// trace and span IDs of the log record. Groups are sent
+// as nested key-value lists.
+type OTLPHandler struct {
+	baseHandler
+	exporter *otlpExporter
+}
+
+// NewOTLPHandler creates an [OTLPHandler] and starts
+// the background goroutine sending batches.
+// If opts is nil, the default options are used.
+func NewOTLPHandler(config OTLPConfig, opts *slog.HandlerOptions) *OTLPHandler {
+	if config.Endpoint == "" {
+		config.Endpoint = "http://localhost:4318/v1/logs"
+	}
+	if config.Client == nil {
+		config.Client = defaultSinkClient
+	}
+	e := &otlpExporter{
+		config:   config,
+		resource: otlpResource(config.Resource),
+	}
+	e.batcher = newBatcher(config.BatchOptions, e.send, spoolJSON[otlpLogRecord]())
+	return &OTLPHandler{
+		baseHandler: newBaseHandler(opts),
+		exporter:    e,
+	}
+}
+
+func (h *OTLPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &OTLPHandler{baseHandler: h.withAttrs(attrs), exporter: h.exporter}
+}
+
+func (h *OTLPHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &OTLPHandler{baseHandler: h.withGroup(name), exporter: h.exporter}
+}
+
+func (h *OTLPHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := attrMap(h.recordAttrs(r))
+	record := otlpLogRecord{
+		TimeUnixNano:         otlpTime(r.Time),
+		ObservedTimeUnixNano: otlpTime(time.Now()),
+		SeverityNumber:       otlpSeverityNumber(r.Level),
+		SeverityText:         r.Level.String(),
+		Body:                 otlpAnyValue{StringValue: &r.Message},
+	}
+	if traceID, ok := popValue(attrs, "trace_id"); ok {
+		record.TraceID = traceID.String()
+	}
+	if spanID, ok := popValue(attrs, "span_id"); ok {
+		record.SpanID = spanID.String()
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		if source, ok := parseCaller(slog.StringValue(recordSource(r))); ok {
+			attrs["code.filepath"] = slog.StringValue(source.File)
+			attrs["code.lineno"] = slog.IntValue(source.Line)
+		}
+	}
+	record.Attributes = otlpKeyValues(attrs)
+	return h.exporter.batcher.add(record)
+}
+
+// Flush sends all buffered records.
+func (h *OTLPHandler) Flush(ctx context.Context) error {
+	return h.exporter.batcher.Flush(ctx)
+}
+
+// Close sends all buffered records and stops
+// the background goroutine.
+func (h *OTLPHandler) Close(ctx context.Context) error {
+	return h.exporter.batcher.Close(ctx)
+}
+
+type otlpExporter struct {
+	config   OTLPConfig
+	resource otlpResourceAttrs
+	batcher  *batcher[otlpLogRecord]
+}
+
+func (e *otlpExporter) send(ctx context.Context, records []otlpLogRecord) error {
+	body, err := json.Marshal(otlpLogsData{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: e.resource,
+			ScopeLogs: []otlpScopeLogs{{
+				Scope:      otlpScope{Name: "github.com/zitadel/logging"},
+				LogRecords: records,
+			}},
+		}},
+	})
+	if err != nil {
+		return permanentError{err}
+	}
+	req, err := http.NewRequestWithContext(WithoutClientLogging(ctx), http.MethodPost, e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.config.Headers {
+		req.Header.Set(key, value)
+	}
+	return doSinkRequest(e.config.Client, req)
+}
+
+// otlpSeverityNumber maps slog levels to
+// OpenTelemetry severity numbers, where INFO is 9.
+func otlpSeverityNumber(level slog.Level) int {
+	return min(max(int(level)+9, 1), 24)
+}
+
+func otlpTime(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpResource(attrs map[string]string) otlpResourceAttrs {
+	var resource otlpResourceAttrs
+	for _, key := range slices.Sorted(maps.Keys(attrs)) {
+		value := attrs[key]
+		resource.Attributes = append(resource.Attributes, otlpKeyValue{
+			Key:   key,
+			Value: otlpAnyValue{StringValue: &value},
+		})
+	}
+	return resource
+}
+
+func otlpKeyValues(m map[string]any) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(m))
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		kvs = append(kvs, otlpKeyValue{Key: key, Value: otlpValue(m[key])})
+	}
+	return kvs
+}
+
+func otlpValue(v any) otlpAnyValue {
+	var av otlpAnyValue
+	switch v := v.(type) {
+	case map[string]any:
+		av.KvlistValue = &otlpKvlistValue{Values: otlpKeyValues(v)}
+	case slog.Value:
+		switch v.Kind() {
+		case slog.KindBool:
+			b := v.Bool()
+			av.BoolValue = &b
+		case slog.KindInt64:
+			i := strconv.FormatInt(v.Int64(), 10)
+			av.IntValue = &i
+		case slog.KindDuration:
+			i := strconv.FormatInt(int64(v.Duration()), 10)
+			av.IntValue = &i
+		case slog.KindUint64:
+			i := strconv.FormatUint(v.Uint64(), 10)
+			av.IntValue = &i
+		case slog.KindFloat64:
+			f := v.Float64()
+			av.DoubleValue = &f
+		case slog.KindTime:
+			s := v.Time().Format(time.RFC3339Nano)
+			av.StringValue = &s
+		default:
+			s := jsonString(v)
+			av.StringValue = &s
+		}
+	}
+	return av
+}
+
+// jsonString returns strings and errors as they are,
+// other values are encoded as JSON.
+func jsonString(v slog.Value) string {
+	switch jv := jsonValue(v).(type) {
+	case string:
+		return jv
+	default:
+		b, err := json.Marshal(jv)
+		if err != nil {
+			return fmt.Sprintf("%+v", jv)
+		}
+		return string(b)
+	}
+}
+
+type otlpLogsData struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResourceAttrs `json:"resource"`
+	ScopeLogs []otlpScopeLogs   `json:"scopeLogs"`
+}
+
+type otlpResourceAttrs struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpAnyValue   `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	TraceID              string         `json:"traceId,omitempty"`
+	SpanID               string         `json:"spanId,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string          `json:"stringValue,omitempty"`
+	BoolValue   *bool            `json:"boolValue,omitempty"`
+	IntValue    *string          `json:"intValue,omitempty"`
+	DoubleValue *float64         `json:"doubleValue,omitempty"`
+	KvlistValue *otlpKvlistValue `json:"kvlistValue,omitempty"`
+}
+
+type otlpKvlistValue struct {
+	Values []otlpKeyValue `json:"values"`
+}
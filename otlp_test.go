@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCollector returns a server decoding
+// the posted OTLP payloads into the returned channel.
+func newTestCollector(t *testing.T, status int) (*httptest.Server, <-chan otlpLogsData) {
+	t.Helper()
+	payloads := make(chan otlpLogsData, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		var payload otlpLogsData
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads <- payload
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, payloads
+}
+
+func TestOTLPHandler(t *testing.T) {
+	ts, payloads := newTestCollector(t, http.StatusOK)
+	h := NewOTLPHandler(OTLPConfig{
+		Endpoint: ts.URL + "/v1/logs",
+		Headers:  map[string]string{"Authorization": "secret"},
+		Resource: map[string]string{"service.name": "zitadel"},
+		BatchOptions: BatchOptions{
+			BatchSize:     2,
+			FlushInterval: time.Hour,
+		},
+	}, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+	logger := slog.New(h).With("trace_id", "0af7651916cd43dd8448eb211c80319c")
+	logger.Debug("first", "n", 1)
+	logger.WithGroup("http").Warn("second",
+		slog.Group("request", "method", "GET"),
+		slog.Bool("slow", true),
+		slog.Float64("ratio", 0.5),
+		slog.Duration("duration", time.Second),
+	)
+
+	var payload otlpLogsData
+	select {
+	case payload = <-payloads:
+	case <-time.After(time.Second):
+		t.Fatal("no payload received")
+	}
+	require.NoError(t, h.Close(context.Background()))
+
+	require.Len(t, payload.ResourceLogs, 1)
+	resource := payload.ResourceLogs[0].Resource.Attributes
+	assert.Equal(t, "service.name", resource[0].Key)
+	assert.Equal(t, "zitadel", *resource[0].Value.StringValue)
+
+	records := payload.ResourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, records, 2)
+	assert.Equal(t, 5, records[0].SeverityNumber)
+	assert.Equal(t, "DEBUG", records[0].SeverityText)
+	assert.Equal(t, "first", *records[0].Body.StringValue)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", records[0].TraceID)
+	assert.NotEqual(t, "0", records[0].TimeUnixNano)
+
+	got, err := json.Marshal(records[1].Attributes)
+	require.NoError(t, err)
+	assert.Equal(t, 13, records[1].SeverityNumber)
+	assert.JSONEq(t, `[
+		{"key":"http","value":{"kvlistValue":{"values":[
+			{"key":"duration","value":{"intValue":"1000000000"}},
+			{"key":"ratio","value":{"doubleValue":0.5}},
+			{"key":"request","value":{"kvlistValue":{"values":[
+				{"key":"method","value":{"stringValue":"GET"}}
+			]}}},
+			{"key":"slow","value":{"boolValue":true}}
+		]}}}
+	]`, string(got))
+}
+
+func TestOTLPHandler_error(t *testing.T) {
+	ts, _ := newTestCollector(t, http.StatusServiceUnavailable)
+	h := NewOTLPHandler(OTLPConfig{
+		Endpoint: ts.URL + "/v1/logs",
+		Headers:  map[string]string{"Authorization": "secret"},
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+		},
+	}, nil)
+	slog.New(h).Info("lost")
+	err := h.Flush(context.Background())
+	assert.ErrorContains(t, err, "503 Service Unavailable")
+	require.NoError(t, h.Close(context.Background()))
+}
+
+func Test_otlpSeverityNumber(t *testing.T) {
+	assert.Equal(t, 1, otlpSeverityNumber(slog.LevelDebug-10))
+	assert.Equal(t, 5, otlpSeverityNumber(slog.LevelDebug))
+	assert.Equal(t, 9, otlpSeverityNumber(slog.LevelInfo))
+	assert.Equal(t, 17, otlpSeverityNumber(slog.LevelError))
+	assert.Equal(t, 24, otlpSeverityNumber(slog.LevelError+20))
+}
@@ -0,0 +1,299 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Output selects the destination of the logs in [Config].
+// By default logs are written to stderr, using the
+// configured formatter.
+type Output struct {
+	// Type is one of the Output constants.
+	Type string `json:"type"`
+
+	OTLP     OTLPConfig     `json:"otlp"`
+	Syslog   SyslogConfig   `json:"syslog"`
+	Journald JournaldConfig `json:"journald"`
+	GELF     GELFConfig     `json:"gelf"`
+	Fluent   FluentConfig   `json:"fluent"`
+	Loki     LokiConfig     `json:"loki"`
+
+	Elasticsearch ElasticsearchConfig `json:"elasticsearch"`
+	Splunk        SplunkConfig        `json:"splunk"`
+}
+
+const (
+	// OutputStderr writes logs to stderr, using the formatter.
+	OutputStderr = "stderr"
+	// OutputOTLP sends logs to an OpenTelemetry collector.
+	// See [OTLPHandler].
+	OutputOTLP = "otlp"
+	// OutputSyslog sends logs to a syslog server.
+	// See [SyslogHandler].
+	OutputSyslog = "syslog"
+	// OutputJournald writes logs to the systemd journal.
+	// See [JournaldHandler].
+	OutputJournald = "journald"
+	// OutputGELF sends logs to Graylog.
+	// See [GELFHandler].
+	OutputGELF = "gelf"
+	// OutputFluent sends logs to Fluentd or Fluent Bit.
+	// See [FluentHandler].
+	OutputFluent = "fluent"
+	// OutputLoki pushes logs to Grafana Loki.
+	// See [LokiHandler].
+	OutputLoki = "loki"
+	// OutputElasticsearch indexes logs in Elasticsearch or OpenSearch.
+	// See [ElasticsearchHandler].
+	OutputElasticsearch = "elasticsearch"
+	// OutputSplunk sends logs to the Splunk HTTP Event Collector.
+	// See [SplunkHandler].
+	OutputSplunk = "splunk"
+)
+
+// sink creates the sink for the configured type.
+// It returns nil for stderr.
+func (o *Output) sink(opts *slog.HandlerOptions) (Sink, error) {
+	switch o.Type {
+	case OutputStderr, "":
+		return nil, nil
+	case OutputOTLP:
+		return NewOTLPHandler(o.OTLP, opts), nil
+	case OutputSyslog:
+		return NewSyslogHandler(o.Syslog, opts)
+	case OutputJournald:
+		return NewJournaldHandler(o.Journald, opts)
+	case OutputGELF:
+		return NewGELFHandler(o.GELF, opts)
+	case OutputFluent:
+		return NewFluentHandler(o.Fluent, opts)
+	case OutputLoki:
+		return NewLokiHandler(o.Loki, opts)
+	case OutputElasticsearch:
+		return NewElasticsearchHandler(o.Elasticsearch, opts), nil
+	case OutputSplunk:
+		return NewSplunkHandler(o.Splunk, opts), nil
+	default:
+		return nil, fmt.Errorf("%s output not supported", o.Type)
+	}
+}
+
+// OutputConfig is an entry of the Outputs in [Config].
+// The fields of the [Output] are inlined.
+type OutputConfig struct {
+	Output `yaml:",inline"`
+
+	// Level is the minimum level of the output,
+	// defaults to the Level of the config.
+	Level string `json:"level"`
+	// Format of the stderr output, one of the Formatter
+	// constants, defaults to the format of the config.
+	Format string `json:"format"`
+	// Filter selects the records by their attributes.
+	Filter OutputFilter `json:"filter"`
+}
+
+// OutputFilter selects records by their attributes,
+// with the keys of groups joined by a dot.
+// Values are compared in their text representation.
+type OutputFilter struct {
+	// Include passes only the records having all of the attributes.
+	Include map[string]string `json:"include"`
+	// Exclude drops the records having any of the attributes.
+	Exclude map[string]string `json:"exclude"`
+}
+
+func (f OutputFilter) match(_ context.Context, r slog.Record) bool {
+	attrs := make(map[string]string, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		flattenAttr(attrs, "", a)
+		return true
+	})
+	for key, value := range f.Include {
+		if v, ok := attrs[key]; !ok || v != value {
+			return false
+		}
+	}
+	for key, value := range f.Exclude {
+		if v, ok := attrs[key]; ok && v == value {
+			return false
+		}
+	}
+	return true
+}
+
+func flattenAttr(m map[string]string, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() != slog.KindGroup {
+		m[prefix+a.Key] = v.String()
+		return
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, attr := range v.Group() {
+		flattenAttr(m, prefix, attr)
+	}
+}
+
+// outputsHandler creates a [MultiHandler]
+// with a handler for each of the Outputs.
+func (c *Config) outputsHandler(opts *slog.HandlerOptions) (*MultiHandler, error) {
+	var errs []error
+	handlers := make([]slog.Handler, 0, len(c.Outputs))
+	for i, output := range c.Outputs {
+		handler, err := c.outputHandler(output, *opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("outputs[%d]: %w", i, err))
+			continue
+		}
+		handlers = append(handlers, handler)
+	}
+	multi := NewMultiHandler(handlers...)
+	if err := errors.Join(errs...); err != nil {
+		_ = multi.Close(context.Background())
+		return nil, err
+	}
+	return multi, nil
+}
+
+func (c *Config) outputHandler(output OutputConfig, opts slog.HandlerOptions) (slog.Handler, error) {
+	if output.Level != "" {
+		level, err := parseLevel(output.Level)
+		if err != nil {
+			return nil, err
+		}
+		opts.Level = level
+	}
+	sink, err := output.sink(&opts)
+	if err != nil {
+		return nil, err
+	}
+	var handler slog.Handler = sink
+	if sink == nil {
+		format := output.Format
+		if format == "" {
+			format = c.Formatter.Format
+		}
+		if handler, err = c.formatHandler(format, &opts); err != nil {
+			return nil, err
+		}
+	}
+	if len(output.Filter.Include) > 0 || len(output.Filter.Exclude) > 0 {
+		handler = NewFilterHandler(handler, nil, output.Filter.match)
+	}
+	return handler, nil
+}
+
+// setOutput sends the logrus entries to the configured outputs
+// or sink instead of stderr. With Async, the outputs are
+// wrapped by an asynchronous queue.
+func (c *Config) setOutput() error {
+	if c.Async != nil {
+		if err := c.Async.validate(); err != nil {
+			return err
+		}
+	}
+	// the level of the logger is coarser, like INFO for "INFO+2".
+	level := slogLevel(log.Level)
+	if c.Level != "" {
+		level, _ = parseLevel(c.Level)
+	}
+	sink, err := c.outputSink(c.handlerOptions(level))
+	if err != nil {
+		return err
+	}
+	// entries below the level of the logger
+	// would never reach the outputs.
+	for _, output := range c.Outputs {
+		if level, err := parseLevel(output.Level); err == nil && logrusLevel(level) > log.Level {
+			log.Level = logrusLevel(level)
+		}
+	}
+	if c.hook == nil {
+		switch {
+		case sink != nil:
+			c.hook = NewSlogHook(sink)
+		case c.Async != nil:
+			w := NewAsyncWriter(log.Out, *c.Async)
+			c.closers = append(c.closers, w)
+			c.hook = w.Hook(log.Formatter)
+		default:
+			return nil
+		}
+		c.out = log.Out
+	}
+	log.Out = io.Discard
+	c.replaceHook(c.hook)
+	return nil
+}
+
+// replaceHook replaces the hook added to the logger
+// by a previous call of setOutput with hook, if not nil.
+func (c *Config) replaceHook(hook logrus.Hook) {
+	hooks := make(logrus.LevelHooks, len(log.Hooks))
+	for level, levelHooks := range log.Hooks {
+		for _, h := range levelHooks {
+			if h != c.hook {
+				hooks[level] = append(hooks[level], h)
+			}
+		}
+	}
+	if hook != nil {
+		hooks.Add(hook)
+	}
+	(*logrus.Logger)(log).ReplaceHooks(hooks)
+	c.hook = hook
+}
+
+// outputSink returns the sink for the Outputs or the Output,
+// wrapped by an [AsyncHandler] with Async. The sink is created
+// once and kept until [Config.Close], so the logrus and slog
+// loggers share it. It returns nil for stderr.
+func (c *Config) outputSink(opts *slog.HandlerOptions) (Sink, error) {
+	if c.sink != nil {
+		return c.sink, nil
+	}
+	var (
+		sink Sink
+		err  error
+	)
+	if len(c.Outputs) > 0 {
+		sink, err = c.outputsHandler(opts)
+	} else {
+		sink, err = c.Output.sink(opts)
+	}
+	if err != nil || sink == nil {
+		return nil, err
+	}
+	if c.Async != nil {
+		sink = NewAsyncHandler(sink, *c.Async)
+	}
+	c.sink = sink
+	c.closers = append(c.closers, sink)
+	return sink, nil
+}
+
+// Close closes all sinks and async outputs created by
+// [Config.SetLogger] and [Config.Slog], sending buffered records.
+// The logger writes to its previous output again.
+func (c *Config) Close(ctx context.Context) error {
+	if c.hook != nil {
+		c.replaceHook(nil)
+		log.Out = c.out
+		c.out = nil
+	}
+	errs := make([]error, 0, len(c.closers))
+	for _, closer := range c.closers {
+		errs = append(errs, closer.Close(ctx))
+	}
+	c.sink = nil
+	c.closers = nil
+	return errors.Join(errs...)
+}
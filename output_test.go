@@ -0,0 +1,291 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfig_outputOTLP(t *testing.T) {
+	t.Cleanup(func() {
+		log = (*logger)(logrus.StandardLogger())
+	})
+	ts, payloads := newTestCollector(t, http.StatusOK)
+
+	var c Config
+	err := json.Unmarshal(fmt.Appendf(nil, `{
+		"level": "info",
+		"localLogger": true,
+		"output": {
+			"type": "otlp",
+			"otlp": {
+				"endpoint": "%s/v1/logs",
+				"headers": {"Authorization": "secret"},
+				"flushInterval": %d
+			}
+		}
+	}`, ts.URL, time.Hour), &c)
+	require.NoError(t, err)
+	assert.Nil(t, c.sink, "sink created by unmarshal")
+	require.NoError(t, c.SetLogger())
+
+	Info("from logrus")
+	c.Slog().Info("from slog")
+	require.NoError(t, c.Close(context.Background()))
+
+	// the logrus and slog loggers share the sink.
+	payload := <-payloads
+	var messages []string
+	for _, record := range payload.ResourceLogs[0].ScopeLogs[0].LogRecords {
+		messages = append(messages, *record.Body.StringValue)
+	}
+	assert.Equal(t, []string{"from logrus", "from slog"}, messages)
+	assert.Empty(t, payloads)
+}
+
+func TestConfig_outputUnknown(t *testing.T) {
+	var c Config
+	require.NoError(t, json.Unmarshal([]byte(`{"localLogger": true, "output": {"type": "carrier-pigeon"}}`), &c))
+	assert.EqualError(t, c.SetLogger(), "carrier-pigeon output not supported")
+}
+
+func TestConfig_outputSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := Config{
+		Level: "info",
+		Output: Output{
+			Type: OutputSyslog,
+			Syslog: SyslogConfig{
+				Network:  "udp",
+				Address:  conn.LocalAddr().String(),
+				Hostname: "host",
+				AppName:  "app",
+			},
+		},
+	}
+	c.Slog().Warn("from slog")
+	require.NoError(t, c.Close(context.Background()))
+	msgs := readDatagrams(t, conn, 1)
+	assert.Regexp(t, `^<12>1 \S+ host app \d+ - - from slog$`, msgs[0])
+}
+
+func TestConfig_outputGELF(t *testing.T) {
+	t.Cleanup(func() {
+		log = (*logger)(logrus.StandardLogger())
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := Config{
+		Level:       "info",
+		LocalLogger: true,
+		Output: Output{
+			Type: OutputGELF,
+			GELF: GELFConfig{
+				Address:     conn.LocalAddr().String(),
+				Compression: "none",
+			},
+		},
+	}
+	require.NoError(t, c.SetLogger())
+	WithFields("user", "gigi").Warn("from logrus")
+	require.NoError(t, c.Close(context.Background()))
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(readDatagrams(t, conn, 1)[0]), &got))
+	assert.Equal(t, "from logrus", got["short_message"])
+	assert.Equal(t, "gigi", got["_user"])
+	assert.Equal(t, float64(4), got["level"])
+}
+
+func TestConfig_setLoggerTwice(t *testing.T) {
+	t.Cleanup(func() {
+		log = (*logger)(logrus.StandardLogger())
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := Config{
+		Level:       "info",
+		LocalLogger: true,
+		Output: Output{
+			Type: OutputSyslog,
+			Syslog: SyslogConfig{
+				Network:  "udp",
+				Address:  conn.LocalAddr().String(),
+				Hostname: "host",
+				AppName:  "app",
+			},
+		},
+	}
+	log = (*logger)(logrus.New())
+	require.NoError(t, c.SetLogger())
+	require.NoError(t, c.SetLogger())
+	assert.Len(t, log.Hooks[logrus.InfoLevel], 1)
+	Info("once")
+	require.NoError(t, c.Close(context.Background()))
+	assert.Empty(t, log.Hooks[logrus.InfoLevel])
+	assert.Equal(t, os.Stderr, log.Out)
+
+	require.NoError(t, c.SetLogger())
+	assert.Len(t, log.Hooks[logrus.InfoLevel], 1)
+	Info("again")
+	require.NoError(t, c.Close(context.Background()))
+
+	msgs := readDatagrams(t, conn, 2)
+	assert.Regexp(t, ` once$`, msgs[0])
+	assert.Regexp(t, ` again$`, msgs[1])
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+	_, _, err = conn.ReadFrom(make([]byte, 1024))
+	assert.Error(t, err, "unexpected datagram")
+}
+
+func TestConfig_async(t *testing.T) {
+	t.Cleanup(func() {
+		log = (*logger)(logrus.StandardLogger())
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var c Config
+	err = json.Unmarshal(fmt.Appendf(nil, `{
+		"level": "info",
+		"localLogger": true,
+		"async": {"queueSize": 10, "dropPolicy": "oldest", "keepErrors": true},
+		"output": {
+			"type": "syslog",
+			"syslog": {"network": "udp", "address": "%s", "hostname": "host", "appName": "app"}
+		}
+	}`, conn.LocalAddr()), &c)
+	require.NoError(t, err)
+	assert.Equal(t, &AsyncOptions{QueueSize: 10, DropPolicy: DropOldest, KeepErrors: true}, c.Async)
+	require.NoError(t, c.SetLogger())
+
+	Warn("from logrus")
+	require.NoError(t, c.Close(context.Background()))
+	msgs := readDatagrams(t, conn, 1)
+	assert.Regexp(t, `^<12>1 \S+ host app \d+ - .* from logrus$`, msgs[0])
+}
+
+func TestConfig_asyncUnknownPolicy(t *testing.T) {
+	var c Config
+	require.NoError(t, json.Unmarshal([]byte(`{"localLogger": true, "async": {"dropPolicy": "random"}}`), &c))
+	assert.EqualError(t, c.SetLogger(), "random drop policy not supported")
+}
+
+// captureStderr redirects stderr to a file,
+// which is returned by the returned function.
+func captureStderr(t *testing.T) func() string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stderr")
+	require.NoError(t, err)
+	stderr := os.Stderr
+	os.Stderr = f
+	t.Cleanup(func() {
+		os.Stderr = stderr
+		f.Close()
+	})
+	return func() string {
+		b, err := os.ReadFile(f.Name())
+		require.NoError(t, err)
+		return string(b)
+	}
+}
+
+func TestConfig_outputs(t *testing.T) {
+	t.Cleanup(func() {
+		log = (*logger)(logrus.StandardLogger())
+	})
+	stderr := captureStderr(t)
+	ts, payloads := newTestCollector(t, http.StatusOK)
+
+	var c Config
+	err := json.Unmarshal(fmt.Appendf(nil, `{
+		"level": "info",
+		"localLogger": true,
+		"outputs": [
+			{"type": "stderr", "level": "debug", "format": "logfmt"},
+			{
+				"type": "otlp",
+				"level": "error",
+				"otlp": {"endpoint": "%s/v1/logs", "headers": {"Authorization": "secret"}},
+				"filter": {"exclude": {"audit": "true"}}
+			}
+		]
+	}`, ts.URL), &c)
+	require.NoError(t, err)
+	require.NoError(t, c.SetLogger())
+	assert.Equal(t, logrus.DebugLevel, log.Level)
+
+	Debug("debug")
+	Error("from logrus")
+	logger := c.Slog()
+	logger.Info("from slog")
+	logger.Error("audited", "audit", true)
+	logger.Error("failed")
+	require.NoError(t, c.Close(context.Background()))
+
+	out := stderr()
+	for _, msg := range []string{"msg=debug", `msg="from logrus"`, `msg="from slog"`, "msg=audited", "msg=failed"} {
+		assert.Contains(t, out, msg)
+	}
+	payload := <-payloads
+	var messages []string
+	for _, record := range payload.ResourceLogs[0].ScopeLogs[0].LogRecords {
+		messages = append(messages, *record.Body.StringValue)
+	}
+	assert.Equal(t, []string{"from logrus", "failed"}, messages)
+	assert.Empty(t, payloads)
+}
+
+func TestConfig_outputsYAML(t *testing.T) {
+	t.Cleanup(func() {
+		log = (*logger)(logrus.StandardLogger())
+	})
+	var c Config
+	err := yaml.Unmarshal([]byte(`
+level: warn
+locallogger: true
+outputs:
+  - type: stderr
+    level: info
+    format: json
+  - type: stderr
+    filter:
+      include:
+        component: auth
+`), &c)
+	require.NoError(t, err)
+	assert.Equal(t, []OutputConfig{
+		{Output: Output{Type: OutputStderr}, Level: "info", Format: FormatterJSON},
+		{Output: Output{Type: OutputStderr}, Filter: OutputFilter{Include: map[string]string{"component": "auth"}}},
+	}, c.Outputs)
+	require.NoError(t, c.SetLogger())
+	assert.Equal(t, logrus.InfoLevel, log.Level)
+	require.NoError(t, c.Close(context.Background()))
+}
+
+func TestConfig_outputsInvalid(t *testing.T) {
+	var c Config
+	require.NoError(t, json.Unmarshal([]byte(`{"localLogger": true, "outputs": [
+		{"type": "stderr", "format": "xml"},
+		{"type": "stderr", "level": "loud"}
+	]}`), &c))
+	assert.EqualError(t, c.SetLogger(), "outputs[0]: xml formatter not supported\noutputs[1]: not a valid level: \"loud\"")
+}
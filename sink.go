@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sink is a [slog.Handler] which delivers records to a
+// destination other than a local writer, like a log collector.
+// Records might be buffered, so Close must be called
+// before the program exits.
+//
+// Handlers returned by WithAttrs and WithGroup share
+// the destination with the Sink.
+type Sink interface {
+	slog.Handler
+
+	// Flush delivers all buffered records.
+	Flush(ctx context.Context) error
+	// Close flushes the buffered records and releases
+	// all resources. Records handled after Close are dropped.
+	Close(ctx context.Context) error
+}
+
+// flushHandler flushes the handler, if it implements Flush.
+func flushHandler(ctx context.Context, handler slog.Handler) error {
+	if f, ok := handler.(interface{ Flush(context.Context) error }); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// closeHandler closes the handler, if it implements Close.
+func closeHandler(ctx context.Context, handler slog.Handler) error {
+	if c, ok := handler.(interface{ Close(context.Context) error }); ok {
+		return c.Close(ctx)
+	}
+	return nil
+}
+
+// NewSlogHook returns a [logrus.Hook] which passes all entries
+// to the handler. This allows using the handlers and sinks of this
+// package with logrus.
+// Fields are passed as attributes, nested maps as groups.
+func NewSlogHook(handler slog.Handler) logrus.Hook {
+	return &slogHook{handler: handler}
+}
+
+type slogHook struct {
+	handler slog.Handler
+}
+
+func (h *slogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *slogHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := slogLevel(entry.Level)
+	if !h.handler.Enabled(ctx, level) {
+		return nil
+	}
+	var pc uintptr
+	if entry.HasCaller() {
+		pc = entry.Caller.PC
+	}
+	r := slog.NewRecord(entry.Time, level, entry.Message, pc)
+	r.AddAttrs(fieldsToAttrs(entry.Data)...)
+	return h.handler.Handle(ctx, r)
+}
+
+// fieldsToAttrs converts logrus fields to attributes,
+// sorted by key.
+func fieldsToAttrs(fields map[string]any) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for key, value := range fields {
+		switch v := value.(type) {
+		case map[string]any:
+			attrs = append(attrs, slog.Attr{Key: key, Value: slog.GroupValue(fieldsToAttrs(v)...)})
+		case logrus.Fields:
+			attrs = append(attrs, slog.Attr{Key: key, Value: slog.GroupValue(fieldsToAttrs(v)...)})
+		default:
+			attrs = append(attrs, slog.Any(key, value))
+		}
+	}
+	slices.SortFunc(attrs, func(a, b slog.Attr) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return attrs
+}
@@ -0,0 +1,42 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogHook(t *testing.T) {
+	out := new(strings.Builder)
+	handler := slog.NewJSONHandler(out, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := logrus.New()
+	logger.SetOutput(new(bytes.Buffer))
+	logger.SetLevel(logrus.TraceLevel)
+	logger.AddHook(NewSlogHook(handler))
+
+	logger.WithFields(logrus.Fields{
+		"b":       2,
+		"a":       "one",
+		"request": map[string]any{"method": "GET"},
+	}).Warn("careful")
+	logger.Debug("not enabled in handler")
+
+	assert.JSONEq(t, `{
+		"level":"WARN",
+		"msg":"careful",
+		"a":"one",
+		"b":2,
+		"request":{"method":"GET"}
+	}`, out.String())
+}
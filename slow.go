@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PathSlowThresholds returns a function which can be passed to
+// [WithSlowThresholdFunc] or [WithClientSlowThresholdFunc].
+// The threshold of the longest path prefix in routes matching the
+// request's URL path is returned. If no prefix matches, fallback is returned.
+func PathSlowThresholds(routes map[string]time.Duration, fallback time.Duration) func(*http.Request) time.Duration {
+	return func(req *http.Request) time.Duration {
+		var (
+			matched   string
+			threshold = fallback
+		)
+		for prefix, d := range routes {
+			if strings.HasPrefix(req.URL.Path, prefix) && len(prefix) > len(matched) {
+				matched = prefix
+				threshold = d
+			}
+		}
+		return threshold
+	}
+}
+
+func constantThreshold(d time.Duration) func(*http.Request) time.Duration {
+	return func(*http.Request) time.Duration {
+		return d
+	}
+}
+
+// isSlow reports if duration exceeds the threshold for the request.
+// A nil threshold func or a threshold of 0 disables slow detection.
+func isSlow(threshold func(*http.Request) time.Duration, req *http.Request, duration time.Duration) bool {
+	if threshold == nil {
+		return false
+	}
+	t := threshold(req)
+	return t > 0 && duration > t
+}
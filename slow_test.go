@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathSlowThresholds(t *testing.T) {
+	threshold := PathSlowThresholds(map[string]time.Duration{
+		"/oauth/":             time.Second,
+		"/oauth/v2/authorize": 5 * time.Second,
+	}, 100*time.Millisecond)
+
+	tests := []struct {
+		path string
+		want time.Duration
+	}{
+		{"/", 100 * time.Millisecond},
+		{"/oauth/v2/token", time.Second},
+		{"/oauth/v2/authorize", 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "https://example.com"+tt.path, nil)
+			assert.Equal(t, tt.want, threshold(req))
+		})
+	}
+}
+
+func Test_isSlow(t *testing.T) {
+	req := httptest.NewRequest("GET", "https://example.com/", nil)
+	assert.False(t, isSlow(nil, req, time.Hour))
+	assert.False(t, isSlow(constantThreshold(0), req, time.Hour))
+	assert.False(t, isSlow(constantThreshold(time.Second), req, time.Second))
+	assert.True(t, isSlow(constantThreshold(time.Second), req, time.Second+1))
+}
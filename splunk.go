@@ -0,0 +1,180 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// SplunkConfig configures the [SplunkHandler].
+type SplunkConfig struct {
+	// URL of the HTTP Event Collector,
+	// defaults to "https://localhost:8088".
+	URL string `json:"url"`
+	// Token of the HEC input.
+	Token string `json:"token"`
+	// Channel is the ID sent as X-Splunk-Request-Channel header,
+	// defaults to a random UUID.
+	Channel string `json:"channel"`
+	// Index, SourceType and Source are sent with each event,
+	// if set. Otherwise the defaults of the HEC input apply.
+	Index      string `json:"index"`
+	SourceType string `json:"sourceType" yaml:"sourceType"`
+	Source     string `json:"source"`
+	// Host defaults to [os.Hostname].
+	Host string `json:"host"`
+	// Client is used to send requests,
+	// defaults to a client with a timeout of 10 seconds.
+	Client *http.Client `json:"-" yaml:"-"`
+
+	BatchOptions `yaml:",inline"`
+	RetryOptions `yaml:",inline"`
+}
+
+// SplunkHandler is a [Sink] sending records in batches
+// to the event endpoint of the Splunk HTTP Event Collector.
+//
+// The message is sent as event, with the time in epoch seconds.
+// The level and the attributes are sent as indexed fields,
+// with groups joined by a dot. With AddSource, the source
+// is added as "caller" field.
+// Requests failing with an error or a 429, 502, 503 or 504
+// status code are retried as configured by the RetryOptions.
+type SplunkHandler struct {
+	baseHandler
+	exporter *splunkExporter
+}
+
+// NewSplunkHandler creates a [SplunkHandler] and starts
+// the background goroutine sending batches.
+// If opts is nil, the default options are used.
+func NewSplunkHandler(config SplunkConfig, opts *slog.HandlerOptions) *SplunkHandler {
+	if config.URL == "" {
+		config.URL = "https://localhost:8088"
+	}
+	if config.Channel == "" {
+		config.Channel = newUUID()
+	}
+	if config.Host == "" {
+		config.Host, _ = os.Hostname()
+	}
+	if config.Client == nil {
+		config.Client = defaultSinkClient
+	}
+	config.RetryOptions = config.RetryOptions.withDefaults()
+	e := &splunkExporter{config: config}
+	e.batcher = newBatcher(config.BatchOptions, e.send, spoolBytes)
+	return &SplunkHandler{
+		baseHandler: newBaseHandler(opts),
+		exporter:    e,
+	}
+}
+
+func (h *SplunkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &SplunkHandler{baseHandler: h.withAttrs(attrs), exporter: h.exporter}
+}
+
+func (h *SplunkHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SplunkHandler{baseHandler: h.withGroup(name), exporter: h.exporter}
+}
+
+func (h *SplunkHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := h.recordAttrs(r)
+	fields := make(map[string]string, len(attrs)+2)
+	for _, a := range attrs {
+		fields[a.key(".")] = syslogValue(a.attr.Value)
+	}
+	if a, ok := h.builtinAttr(slog.Any(slog.LevelKey, r.Level)); ok {
+		fields[a.Key] = a.Value.String()
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		fields["caller"] = recordSource(r)
+	}
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	config := &h.exporter.config
+	event, err := json.Marshal(splunkEvent{
+		Time:       float64(t.UnixMilli()) / 1e3,
+		Host:       config.Host,
+		Source:     config.Source,
+		SourceType: config.SourceType,
+		Index:      config.Index,
+		Event:      r.Message,
+		Fields:     fields,
+	})
+	if err != nil {
+		return err
+	}
+	return h.exporter.batcher.add(event)
+}
+
+// Flush sends all buffered records.
+func (h *SplunkHandler) Flush(ctx context.Context) error {
+	return h.exporter.batcher.Flush(ctx)
+}
+
+// Close sends all buffered records and stops
+// the background goroutine.
+func (h *SplunkHandler) Close(ctx context.Context) error {
+	return h.exporter.batcher.Close(ctx)
+}
+
+type splunkEvent struct {
+	Time       float64           `json:"time"`
+	Host       string            `json:"host,omitempty"`
+	Source     string            `json:"source,omitempty"`
+	SourceType string            `json:"sourcetype,omitempty"`
+	Index      string            `json:"index,omitempty"`
+	Event      string            `json:"event"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+type splunkExporter struct {
+	config  SplunkConfig
+	batcher *batcher[[]byte]
+}
+
+// send posts the events, which are concatenated
+// as supported by the event endpoint.
+func (e *splunkExporter) send(ctx context.Context, events [][]byte) error {
+	body := bytes.Join(events, []byte{'\n'})
+	url := strings.TrimSuffix(e.config.URL, "/") + "/services/collector/event"
+	err := e.config.retry(ctx, func() error {
+		req, err := http.NewRequestWithContext(WithoutClientLogging(ctx), http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return permanentError{err}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Splunk "+e.config.Token)
+		req.Header.Set("X-Splunk-Request-Channel", e.config.Channel)
+		return doSinkRequest(e.config.Client, req)
+	})
+	if err != nil {
+		return e.batcher.sendError("splunk", len(events), "events", err)
+	}
+	return nil
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplunkHandler(t *testing.T) {
+	events := make(chan []map[string]any, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/collector/event", r.URL.Path)
+		assert.Equal(t, "Splunk token", r.Header.Get("Authorization"))
+		assert.Equal(t, "channel", r.Header.Get("X-Splunk-Request-Channel"))
+		var got []map[string]any
+		dec := json.NewDecoder(r.Body)
+		for dec.More() {
+			var event map[string]any
+			assert.NoError(t, dec.Decode(&event))
+			got = append(got, event)
+		}
+		events <- got
+		w.Write([]byte(`{"text":"Success","code":0}`))
+	}))
+	defer ts.Close()
+
+	h := NewSplunkHandler(SplunkConfig{
+		URL:          ts.URL,
+		Token:        "token",
+		Channel:      "channel",
+		Index:        "main",
+		SourceType:   "_json",
+		Source:       "zitadel",
+		Host:         "host",
+		BatchOptions: BatchOptions{FlushInterval: time.Hour},
+	}, nil)
+
+	r := slog.NewRecord(time.UnixMilli(1715000000123), slog.LevelWarn, "hello", 0)
+	r.AddAttrs(slog.Group("http", slog.Int("status", 404)))
+	require.NoError(t, slog.New(h).With("id", "1").Handler().Handle(context.Background(), r))
+
+	l := logrus.New()
+	l.Out = io.Discard
+	l.AddHook(NewSlogHook(h))
+	l.WithField("user", "gigi").Error("from logrus")
+	require.NoError(t, h.Close(context.Background()))
+
+	got := <-events
+	require.Len(t, got, 2)
+	assert.Equal(t, map[string]any{
+		"time":       1715000000.123,
+		"host":       "host",
+		"source":     "zitadel",
+		"sourcetype": "_json",
+		"index":      "main",
+		"event":      "hello",
+		"fields": map[string]any{
+			"id":          "1",
+			"http.status": "404",
+			"level":       "WARN",
+		},
+	}, got[0])
+	assert.Equal(t, "from logrus", got[1]["event"])
+	assert.Equal(t, map[string]any{"user": "gigi", "level": "ERROR"}, got[1]["fields"])
+}
+
+func TestSplunkHandler_error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"text":"Invalid token","code":4}`))
+	}))
+	defer ts.Close()
+
+	h := NewSplunkHandler(SplunkConfig{URL: ts.URL}, nil)
+	slog.New(h).Info("hello")
+	err := h.Close(context.Background())
+	assert.ErrorContains(t, err, "dropped 1 events")
+	assert.ErrorContains(t, err, "Invalid token")
+}
+
+func TestSplunkHandler_spool(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	events := make(chan string, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		dec := json.NewDecoder(r.Body)
+		for dec.More() {
+			var event struct {
+				Event string `json:"event"`
+			}
+			assert.NoError(t, dec.Decode(&event))
+			events <- event.Event
+		}
+	}))
+	defer ts.Close()
+
+	h := NewSplunkHandler(SplunkConfig{
+		URL: ts.URL,
+		BatchOptions: BatchOptions{
+			FlushInterval: time.Hour,
+			Spool:         SpoolOptions{Dir: t.TempDir()},
+		},
+		RetryOptions: RetryOptions{MaxAttempts: 1},
+	}, nil)
+	slog.New(h).Info("one")
+	assert.ErrorContains(t, h.Flush(context.Background()), "spooling records")
+
+	down.Store(false)
+	slog.New(h).Info("two")
+	require.NoError(t, h.Close(context.Background()))
+	assert.Equal(t, "one", <-events)
+	assert.Equal(t, "two", <-events)
+}
+
+func Test_newUUID(t *testing.T) {
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), newUUID())
+}
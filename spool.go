@@ -0,0 +1,360 @@
+package logging
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SpoolOptions configure the spool directory, where network
+// sinks persist records which could not be sent.
+// The records are sent again, in the order they were logged,
+// once the destination is available. They survive restarts
+// of the process, so records are delivered at least once.
+type SpoolOptions struct {
+	// Dir is the directory of the spool, which is created if needed.
+	// Spooling is disabled if empty.
+	// Each sink needs its own directory.
+	Dir string `json:"dir"`
+	// MaxSize is the maximum size of the spool in bytes,
+	// defaults to 100 MiB. When exceeded, the oldest
+	// segment files are removed.
+	MaxSize int64 `json:"maxSize" yaml:"maxSize"`
+	// SegmentSize is the size in bytes after which a new
+	// segment file is started, defaults to 8 MiB.
+	SegmentSize int64 `json:"segmentSize" yaml:"segmentSize"`
+}
+
+func (o SpoolOptions) withDefaults() SpoolOptions {
+	if o.MaxSize <= 0 {
+		o.MaxSize = 100 << 20
+	}
+	if o.SegmentSize <= 0 {
+		o.SegmentSize = 8 << 20
+	}
+	o.SegmentSize = min(o.SegmentSize, o.MaxSize)
+	return o
+}
+
+const (
+	spoolSuffix     = ".spool"
+	spoolOffsetFile = "offset"
+	// spoolHeaderSize is the size of the length
+	// and checksum preceding each record.
+	spoolHeaderSize = 8
+)
+
+// spool is a write-ahead log of records, split into segment files.
+// Records are read from the oldest segment, starting at the offset
+// of the first record which was not committed yet.
+// It is not safe for concurrent use.
+type spool struct {
+	opts     SpoolOptions
+	segments []spoolSegment
+	// offset in the first segment.
+	offset int64
+	// w appends to the last segment.
+	w *os.File
+}
+
+type spoolSegment struct {
+	id   uint64
+	size int64
+}
+
+// openSpool opens the spool directory,
+// continuing with the records left by a previous process.
+func openSpool(opts SpoolOptions) (*spool, error) {
+	s := &spool{opts: opts.withDefaults()}
+	if err := os.MkdirAll(s.opts.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), spoolSuffix)
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		id, err := strconv.ParseUint(name, 16, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		s.segments = append(s.segments, spoolSegment{id: id, size: info.Size()})
+	}
+	slices.SortFunc(s.segments, func(a, b spoolSegment) int {
+		return cmp.Compare(a.id, b.id)
+	})
+	if b, err := os.ReadFile(filepath.Join(s.opts.Dir, spoolOffsetFile)); err == nil && len(s.segments) > 0 {
+		var id uint64
+		var offset int64
+		if _, err := fmt.Sscanf(string(b), "%x %d", &id, &offset); err == nil && id == s.segments[0].id {
+			s.offset = offset
+		}
+	}
+	return s, nil
+}
+
+func (s *spool) path(id uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%016x%s", id, spoolSuffix))
+}
+
+// size returns the size of all segments.
+func (s *spool) size() int64 {
+	var size int64
+	for _, segment := range s.segments {
+		size += segment.size
+	}
+	return size
+}
+
+// empty reports whether all records were committed.
+func (s *spool) empty() bool {
+	return len(s.segments) == 0
+}
+
+// write appends the records to the last segment and syncs it.
+// When the MaxSize would be exceeded, the oldest segments are
+// removed first. It returns the amount of bytes removed.
+func (s *spool) write(records [][]byte) (removed int64, err error) {
+	var buf []byte
+	for _, record := range records {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(record)))
+		buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(record))
+		buf = append(buf, record...)
+	}
+	if int64(len(buf)) > s.opts.MaxSize {
+		return 0, fmt.Errorf("%d bytes exceed the spool size", len(buf))
+	}
+	for !s.empty() && s.size()+int64(len(buf)) > s.opts.MaxSize {
+		removed += s.segments[0].size
+		if err = s.removeFirst(); err != nil {
+			return removed, err
+		}
+	}
+	if s.w == nil || s.segments[len(s.segments)-1].size >= s.opts.SegmentSize {
+		if err = s.rotate(); err != nil {
+			return removed, err
+		}
+	}
+	n, err := s.w.Write(buf)
+	s.segments[len(s.segments)-1].size += int64(n)
+	if err == nil {
+		err = s.w.Sync()
+	}
+	if err != nil {
+		// Records written after a partial record could not be read,
+		// so the next write starts a new segment.
+		_ = s.closeWriter()
+	}
+	return removed, err
+}
+
+// rotate starts a new segment.
+func (s *spool) rotate() error {
+	if err := s.closeWriter(); err != nil {
+		return err
+	}
+	var id uint64 = 1
+	if !s.empty() {
+		id = s.segments[len(s.segments)-1].id + 1
+	}
+	f, err := os.OpenFile(s.path(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	s.w = f
+	s.segments = append(s.segments, spoolSegment{id: id})
+	return nil
+}
+
+// read returns up to n records from the first segment,
+// and the offset after the last record, to be committed.
+// Segments without records left are removed.
+// A truncated or corrupt record, as left by a crash,
+// ends its segment.
+func (s *spool) read(n int) (records [][]byte, end int64, err error) {
+	for !s.empty() {
+		records, end, err = s.readSegment(n)
+		if err != nil || len(records) > 0 {
+			return records, end, err
+		}
+		if err = s.removeFirst(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return nil, 0, nil
+}
+
+func (s *spool) readSegment(n int) (records [][]byte, end int64, err error) {
+	f, err := os.Open(s.path(s.segments[0].id))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	end = s.offset
+	if _, err = f.Seek(end, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	r := bufio.NewReader(f)
+	header := make([]byte, spoolHeaderSize)
+	for len(records) < n {
+		if _, err = io.ReadFull(r, header); err != nil {
+			break
+		}
+		length := int64(binary.BigEndian.Uint32(header))
+		if length > s.opts.MaxSize {
+			break
+		}
+		record := make([]byte, length)
+		if _, err = io.ReadFull(r, record); err != nil || crc32.ChecksumIEEE(record) != binary.BigEndian.Uint32(header[4:]) {
+			break
+		}
+		records = append(records, record)
+		end += spoolHeaderSize + length
+	}
+	return records, end, nil
+}
+
+// commit marks the records up to end as sent.
+// The offset is persisted, so they are not sent again
+// after a restart.
+func (s *spool) commit(end int64) error {
+	if s.empty() {
+		return nil
+	}
+	if end >= s.segments[0].size {
+		return s.removeFirst()
+	}
+	s.offset = end
+	return s.writeOffset()
+}
+
+// removeFirst removes the first segment.
+func (s *spool) removeFirst() error {
+	if len(s.segments) == 1 {
+		if err := s.closeWriter(); err != nil {
+			return err
+		}
+	}
+	err := os.Remove(s.path(s.segments[0].id))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	s.segments = s.segments[1:]
+	s.offset = 0
+	return s.writeOffset()
+}
+
+// writeOffset atomically replaces the offset file.
+func (s *spool) writeOffset() error {
+	path := filepath.Join(s.opts.Dir, spoolOffsetFile)
+	if s.empty() || s.offset == 0 {
+		err := os.Remove(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, fmt.Appendf(nil, "%x %d", s.segments[0].id, s.offset), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *spool) closeWriter() error {
+	if s.w == nil {
+		return nil
+	}
+	err := s.w.Close()
+	s.w = nil
+	return err
+}
+
+// close releases the file of the last segment.
+// The segments are kept for the next process.
+func (s *spool) close() error {
+	return s.closeWriter()
+}
+
+// spoolCodec converts the items of a batcher
+// to spooled records and back.
+type spoolCodec[T any] struct {
+	encode func(T) ([]byte, error)
+	decode func([]byte) (T, error)
+}
+
+// spoolBytes spools items which are already encoded.
+var spoolBytes = &spoolCodec[[]byte]{
+	encode: func(b []byte) ([]byte, error) { return b, nil },
+	decode: func(b []byte) ([]byte, error) { return b, nil },
+}
+
+// spoolJSON spools items which can be encoded as JSON.
+func spoolJSON[T any]() *spoolCodec[T] {
+	return &spoolCodec[T]{
+		encode: func(item T) ([]byte, error) {
+			return json.Marshal(item)
+		},
+		decode: func(b []byte) (item T, err error) {
+			err = json.Unmarshal(b, &item)
+			return item, err
+		},
+	}
+}
+
+// undeliveredError is returned by the send function of
+// a batcher, when only some items of the batch failed.
+// These items are spooled, instead of the whole batch.
+type undeliveredError[T any] struct {
+	items []T
+	err   error
+}
+
+func (e *undeliveredError[T]) Error() string {
+	return e.err.Error()
+}
+
+func (e *undeliveredError[T]) Unwrap() error {
+	return e.err
+}
+
+// sendError describes the n items of a failed batch, which
+// are spooled if there is a spool and err is not permanent.
+// Otherwise they are dropped.
+func (b *batcher[T]) sendError(sink string, n int, items string, err error) error {
+	if b.spool != nil && !errors.As(err, new(permanentError)) {
+		return fmt.Errorf("logging: %s: sending %d %s: %w", sink, n, items, err)
+	}
+	return fmt.Errorf("logging: %s: dropped %d %s: %w", sink, n, items, err)
+}
+
+// undelivered returns the items of the batch
+// which may be sent again after err.
+func undelivered[T any](batch []T, err error) []T {
+	if errors.As(err, new(permanentError)) {
+		return nil
+	}
+	var u *undeliveredError[T]
+	if errors.As(err, &u) {
+		return u.items
+	}
+	return batch
+}
@@ -0,0 +1,289 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readSpool(t *testing.T, s *spool, n int) []string {
+	t.Helper()
+	records, end, err := s.read(n)
+	require.NoError(t, err)
+	require.NoError(t, s.commit(end))
+	var got []string
+	for _, record := range records {
+		got = append(got, string(record))
+	}
+	return got
+}
+
+func spoolFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func Test_spool(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	s, err := openSpool(SpoolOptions{Dir: dir, SegmentSize: 20})
+	require.NoError(t, err)
+	assert.True(t, s.empty())
+
+	_, err = s.write([][]byte{[]byte("one"), []byte("two")})
+	require.NoError(t, err)
+	_, err = s.write([][]byte{[]byte("three")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0000000000000001.spool", "0000000000000002.spool"}, spoolFiles(t, dir))
+	assert.Equal(t, []string{"one"}, readSpool(t, s, 1))
+	require.NoError(t, s.close())
+
+	s, err = openSpool(SpoolOptions{Dir: dir, SegmentSize: 20})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"two"}, readSpool(t, s, 10))
+	_, err = s.write([][]byte{[]byte("four")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"three"}, readSpool(t, s, 10))
+	assert.Equal(t, []string{"four"}, readSpool(t, s, 10))
+	assert.True(t, s.empty())
+	assert.Empty(t, spoolFiles(t, dir))
+	assert.Nil(t, readSpool(t, s, 10))
+	require.NoError(t, s.close())
+}
+
+func Test_spool_maxSize(t *testing.T) {
+	s, err := openSpool(SpoolOptions{Dir: t.TempDir(), MaxSize: 40, SegmentSize: 10})
+	require.NoError(t, err)
+	for _, record := range []string{"record-1", "record-2"} {
+		removed, err := s.write([][]byte{[]byte(record)})
+		require.NoError(t, err)
+		assert.Zero(t, removed)
+	}
+	removed, err := s.write([][]byte{[]byte("record-3")})
+	require.NoError(t, err)
+	assert.EqualValues(t, 16, removed)
+	assert.Equal(t, []string{"record-2"}, readSpool(t, s, 10))
+	assert.Equal(t, []string{"record-3"}, readSpool(t, s, 10))
+
+	_, err = s.write([][]byte{make([]byte, 40)})
+	assert.EqualError(t, err, "48 bytes exceed the spool size")
+	require.NoError(t, s.close())
+}
+
+func Test_spool_truncated(t *testing.T) {
+	dir := t.TempDir()
+	s, err := openSpool(SpoolOptions{Dir: dir})
+	require.NoError(t, err)
+	_, err = s.write([][]byte{[]byte("one"), []byte("two")})
+	require.NoError(t, err)
+	require.NoError(t, s.close())
+	require.NoError(t, os.Truncate(s.path(1), 20))
+
+	s, err = openSpool(SpoolOptions{Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one"}, readSpool(t, s, 10))
+	assert.False(t, s.empty())
+	assert.Nil(t, readSpool(t, s, 10))
+	assert.True(t, s.empty())
+}
+
+// flakySender fails with a temporary error while down.
+type flakySender struct {
+	mu      sync.Mutex
+	down    bool
+	batches [][]int
+}
+
+func (s *flakySender) send(_ context.Context, batch []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return errors.New("unavailable")
+	}
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func (s *flakySender) setDown(down bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.down = down
+}
+
+func (s *flakySender) get() [][]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batches
+}
+
+func Test_batcher_spool(t *testing.T) {
+	dir := t.TempDir()
+	opts := BatchOptions{
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		Spool:         SpoolOptions{Dir: dir},
+	}
+	sender := &flakySender{down: true}
+	b := newBatcher(opts, sender.send, spoolJSON[int]())
+	for i := range 3 {
+		require.NoError(t, b.add(i))
+	}
+	err := b.Flush(context.Background())
+	assert.ErrorContains(t, err, "spooling records until the destination is available: unavailable")
+	require.NoError(t, b.add(3))
+	require.NoError(t, b.Close(context.Background()))
+	assert.Empty(t, sender.get())
+
+	// records survive a restart and are sent before new records.
+	sender.setDown(false)
+	b = newBatcher(opts, sender.send, spoolJSON[int]())
+	require.NoError(t, b.add(4))
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, [][]int{{0, 1}, {2, 3}, {4}}, sender.get())
+	assert.Empty(t, spoolFiles(t, dir))
+}
+
+func Test_batcher_spoolPermanent(t *testing.T) {
+	dir := t.TempDir()
+	b := newBatcher(BatchOptions{FlushInterval: time.Hour, Spool: SpoolOptions{Dir: dir}},
+		func(context.Context, []int) error {
+			return permanentError{errors.New("bad request")}
+		}, spoolJSON[int]())
+	require.NoError(t, b.add(1))
+	assert.EqualError(t, b.Close(context.Background()), "bad request")
+	assert.Empty(t, spoolFiles(t, dir))
+}
+
+func Test_undelivered(t *testing.T) {
+	batch := []int{1, 2, 3}
+	assert.Equal(t, batch, undelivered(batch, errors.New("unavailable")))
+	assert.Nil(t, undelivered(batch, permanentError{errors.New("bad request")}))
+	assert.Equal(t, []int{3}, undelivered(batch, errors.Join(
+		&undeliveredError[int]{items: []int{3}, err: errors.New("timeout")},
+		errors.New("rejected"),
+	)))
+}
+
+func Test_spoolCodecs(t *testing.T) {
+	entry := lokiEntry{
+		labels: map[string]string{"level": "info"},
+		time:   time.Unix(1715000000, 123).UTC(),
+		line:   "msg=hello",
+	}
+	b, err := lokiSpoolCodec.encode(entry)
+	require.NoError(t, err)
+	got, err := lokiSpoolCodec.decode(b)
+	require.NoError(t, err)
+	assert.Equal(t, entry, got)
+
+	doc := esDocument{index: "logs-2024.05.06", body: []byte(`{"msg":"hello"}`)}
+	b, err = esSpoolCodec.encode(doc)
+	require.NoError(t, err)
+	gotDoc, err := esSpoolCodec.decode(b)
+	require.NoError(t, err)
+	assert.Equal(t, doc, gotDoc)
+	_, err = esSpoolCodec.decode([]byte("invalid"))
+	assert.Error(t, err)
+}
+
+func TestSinks_spoolError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed := listener.Addr().String()
+	listener.Close()
+
+	batch := BatchOptions{FlushInterval: time.Hour}
+	retry := RetryOptions{MaxAttempts: 1}
+	tests := []struct {
+		name    string
+		newSink func(batch BatchOptions) (Sink, error)
+		want    string
+	}{
+		{
+			name: "loki",
+			newSink: func(batch BatchOptions) (Sink, error) {
+				return NewLokiHandler(LokiConfig{URL: ts.URL, BatchOptions: batch, RetryOptions: retry}, nil)
+			},
+			want: "loki: %s 1 records",
+		},
+		{
+			name: "splunk",
+			newSink: func(batch BatchOptions) (Sink, error) {
+				return NewSplunkHandler(SplunkConfig{URL: ts.URL, BatchOptions: batch, RetryOptions: retry}, nil), nil
+			},
+			want: "splunk: %s 1 events",
+		},
+		{
+			name: "elasticsearch",
+			newSink: func(batch BatchOptions) (Sink, error) {
+				return NewElasticsearchHandler(ElasticsearchConfig{URL: ts.URL, BatchOptions: batch, RetryOptions: retry}, nil), nil
+			},
+			want: "elasticsearch: %s 1 documents",
+		},
+		{
+			name: "gelf",
+			newSink: func(batch BatchOptions) (Sink, error) {
+				return NewGELFHandler(GELFConfig{Network: "tcp", Address: closed, BatchOptions: batch}, nil)
+			},
+			want: "gelf: %s 1 messages",
+		},
+		{
+			name: "syslog",
+			newSink: func(batch BatchOptions) (Sink, error) {
+				return NewSyslogHandler(SyslogConfig{Network: "tcp", Address: closed, BatchOptions: batch}, nil)
+			},
+			want: "syslog: %s 1 messages",
+		},
+		{
+			name: "fluent",
+			newSink: func(batch BatchOptions) (Sink, error) {
+				return NewFluentHandler(FluentConfig{Address: closed, BatchOptions: batch, RetryOptions: retry}, nil)
+			},
+			want: "fluent: %s 1 records",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, spooled := range []bool{false, true} {
+				batch := batch
+				want := fmt.Sprintf(tt.want, "dropped")
+				if spooled {
+					batch.Spool = SpoolOptions{Dir: t.TempDir()}
+					want = "spooling records until the destination is available: logging: " + fmt.Sprintf(tt.want, "sending")
+				}
+				sink, err := tt.newSink(batch)
+				require.NoError(t, err)
+				slog.New(sink).Info("hello")
+				err = sink.Flush(context.Background())
+				assert.ErrorContains(t, err, want)
+				if spooled {
+					assert.NotContains(t, err.Error(), "dropped")
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				_ = sink.Close(ctx)
+				cancel()
+			}
+		})
+	}
+}
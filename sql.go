@@ -0,0 +1,390 @@
+package logging
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+type SQLOption func(*sqlLogger)
+
+// WithSQLFallbackLogger uses the passed logger if none was
+// found in the context.
+func WithSQLFallbackLogger(logger *slog.Logger) SQLOption {
+	return func(l *sqlLogger) {
+		l.fallback = logger
+	}
+}
+
+// WithSQLDurationFunc allows overriding the query duration
+// for testing.
+func WithSQLDurationFunc(df func(time.Time) time.Duration) SQLOption {
+	return func(l *sqlLogger) {
+		l.duration = df
+	}
+}
+
+// WithSQLGroup groups the log attributes
+// produced by the driver.
+func WithSQLGroup(name string) SQLOption {
+	return func(l *sqlLogger) {
+		l.group = name
+	}
+}
+
+// WithSQLSlowThreshold prints the query line at WARN level
+// with a slow=true attribute, when the query took longer than d.
+func WithSQLSlowThreshold(d time.Duration) SQLOption {
+	return func(l *sqlLogger) {
+		l.slowThreshold = d
+	}
+}
+
+// WithSQLArgValues logs the values of query arguments.
+// By default only the amount of arguments is logged,
+// as values might contain sensitive data.
+func WithSQLArgValues() SQLOption {
+	return func(l *sqlLogger) {
+		l.argValues = true
+	}
+}
+
+// WrapDriver adds slog functionality to a [driver.Driver].
+// The returned driver can be registered with [sql.Register].
+//
+// It attempts to obtain a logger with [FromContext],
+// so queries made with the context of a request
+// served by [Middleware] are logged with the request attributes.
+// If no logger is in the context, it tries to use a fallback logger,
+// which might be set by [WithSQLFallbackLogger].
+// If no logger was found finally, the query is executed
+// without logging.
+//
+// Each query or exec prints a line with the query, the amount of
+// arguments, rows affected and the duration.
+// Errors are printed at ERROR level.
+func WrapDriver(d driver.Driver, opts ...SQLOption) driver.Driver {
+	return &loggedDriver{
+		Driver: d,
+		logger: newSQLLogger(opts),
+	}
+}
+
+// WrapConnector is like [WrapDriver] for a [driver.Connector].
+// The returned connector can be passed to [sql.OpenDB].
+func WrapConnector(c driver.Connector, opts ...SQLOption) driver.Connector {
+	logger := newSQLLogger(opts)
+	return &loggedConnector{
+		Connector: c,
+		driver:    &loggedDriver{Driver: c.Driver(), logger: logger},
+		logger:    logger,
+	}
+}
+
+func newSQLLogger(opts []SQLOption) *sqlLogger {
+	l := &sqlLogger{
+		duration: time.Since,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+type sqlLogger struct {
+	fallback      *slog.Logger
+	duration      func(time.Time) time.Duration
+	group         string
+	slowThreshold time.Duration
+	argValues     bool
+}
+
+// log prints the line for a query.
+// result is nil for queries and failed execs.
+func (l *sqlLogger) log(ctx context.Context, start time.Time, op, query string, args []driver.NamedValue, result driver.Result, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	logger, ok := FromContext(ctx)
+	if !ok {
+		if l.fallback == nil {
+			return
+		}
+		logger = l.fallback
+	}
+	duration := l.duration(start)
+	sqlAttrs := []any{
+		slog.String("query", query),
+		slog.Int("args", len(args)),
+	}
+	if l.argValues {
+		values := make([]any, len(args))
+		for i, arg := range args {
+			values[i] = arg.Value
+		}
+		sqlAttrs = append(sqlAttrs, slog.Any("values", values))
+	}
+	if result != nil {
+		if n, err := result.RowsAffected(); err == nil {
+			sqlAttrs = append(sqlAttrs, slog.Int64("rows_affected", n))
+		}
+	}
+	attrs := []any{
+		slog.Group("sql", sqlAttrs...),
+		slog.Duration("duration", duration),
+	}
+	level := slog.LevelInfo
+	if l.slowThreshold > 0 && duration > l.slowThreshold {
+		attrs = append(attrs, slog.Bool("slow", true))
+		level = slog.LevelWarn
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		level = slog.LevelError
+	}
+	logger.WithGroup(l.group).Log(ctx, level, "sql "+op, attrs...)
+}
+
+type loggedDriver struct {
+	driver.Driver
+	logger *sqlLogger
+}
+
+func (d *loggedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedConn{Conn: conn, logger: d.logger}, nil
+}
+
+// OpenConnector implements [driver.DriverContext].
+func (d *loggedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.Driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &loggedConnector{Connector: c, driver: d, logger: d.logger}, nil
+	}
+	return &dsnConnector{name: name, driver: d}, nil
+}
+
+type dsnConnector struct {
+	name   string
+	driver *loggedDriver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type loggedConnector struct {
+	driver.Connector
+	driver driver.Driver
+	logger *sqlLogger
+}
+
+func (c *loggedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedConn{Conn: conn, logger: c.logger}, nil
+}
+
+func (c *loggedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// loggedConn implements all optional interfaces of [driver.Conn]
+// and falls back to the behavior of [database/sql]
+// if the wrapped Conn does not implement them.
+type loggedConn struct {
+	driver.Conn
+	logger *sqlLogger
+}
+
+func (c *loggedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *loggedConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
+	if cpc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = cpc.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wrapStmt(&loggedStmt{Stmt: stmt, conn: c, query: query}), nil
+}
+
+func (c *loggedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if cbt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return cbt.BeginTx(ctx, opts)
+	}
+	// same as database/sql without [driver.ConnBeginTx]
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+	tx, err := c.Conn.Begin()
+	if err == nil && ctx.Err() != nil {
+		tx.Rollback()
+		return nil, ctx.Err()
+	}
+	return tx, err
+}
+
+func (c *loggedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	c.logger.log(ctx, start, "exec", query, args, result, err)
+	return result, err
+}
+
+func (c *loggedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.logger.log(ctx, start, "query", query, args, nil, err)
+	return rows, err
+}
+
+func (c *loggedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *loggedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *loggedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *loggedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type loggedStmt struct {
+	driver.Stmt
+	conn  *loggedConn
+	query string
+}
+
+func (s *loggedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (result driver.Result, err error) {
+	start := time.Now()
+	if sec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = sec.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			result, err = s.Stmt.Exec(values)
+		}
+	}
+	s.conn.logger.log(ctx, start, "exec", s.query, args, result, err)
+	return result, err
+}
+
+func (s *loggedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
+	start := time.Now()
+	if sqc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = sqc.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	s.conn.logger.log(ctx, start, "query", s.query, args, nil, err)
+	return rows, err
+}
+
+// wrapStmt only implements [driver.NamedValueChecker] and
+// [driver.ColumnConverter] if the wrapped Stmt does,
+// as they change how [database/sql] converts the arguments.
+// Without a checker on the Stmt, the one of the [loggedConn] is used.
+func wrapStmt(s *loggedStmt) driver.Stmt {
+	_, checker := s.Stmt.(driver.NamedValueChecker)
+	_, converter := s.Stmt.(driver.ColumnConverter)
+	switch {
+	case checker && converter:
+		return &checkerConverterStmt{s}
+	case checker:
+		return &checkerStmt{s}
+	case converter:
+		return &converterStmt{s}
+	default:
+		return s
+	}
+}
+
+type checkerStmt struct {
+	*loggedStmt
+}
+
+func (s *checkerStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return s.Stmt.(driver.NamedValueChecker).CheckNamedValue(nv)
+}
+
+type converterStmt struct {
+	*loggedStmt
+}
+
+func (s *converterStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return s.Stmt.(driver.ColumnConverter).ColumnConverter(idx)
+}
+
+type checkerConverterStmt struct {
+	*loggedStmt
+}
+
+func (s *checkerConverterStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return s.Stmt.(driver.NamedValueChecker).CheckNamedValue(nv)
+}
+
+func (s *checkerConverterStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return s.Stmt.(driver.ColumnConverter).ColumnConverter(idx)
+}
+
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("logging: driver does not support named arguments")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
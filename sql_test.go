@@ -0,0 +1,297 @@
+package logging
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errFakeQuery = errors.New("fake query failed")
+
+// fakeDriver is an in-memory driver.
+// All execs affect 3 rows and queries return no rows.
+// The query "fail" returns errFakeQuery.
+// If withContext is set, connections implement
+// [driver.ExecerContext] and [driver.QueryerContext].
+// It also serves as its own [driver.Connector].
+type fakeDriver struct {
+	withContext bool
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	if d.withContext {
+		return fakeContextConn{}, nil
+	}
+	return fakeConn{}, nil
+}
+
+func (d fakeDriver) Connect(context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d fakeDriver) Driver() driver.Driver {
+	return d
+}
+
+// fakeOnlyDriver does not implement [driver.DriverContext].
+type fakeOnlyDriver struct{}
+
+func (fakeOnlyDriver) Open(string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{query: query}, nil
+}
+
+func (fakeConn) Close() error              { return nil }
+func (fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeContextConn struct {
+	fakeConn
+}
+
+func (fakeContextConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if query == "fail" {
+		return nil, errFakeQuery
+	}
+	return driver.RowsAffected(3), nil
+}
+
+func (fakeContextConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if query == "fail" {
+		return nil, errFakeQuery
+	}
+	return fakeRows{}, nil
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if s.query == "fail" {
+		return nil, errFakeQuery
+	}
+	return driver.RowsAffected(3), nil
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	if s.query == "fail" {
+		return nil, errFakeQuery
+	}
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return []string{"n"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+func TestWrapConnector(t *testing.T) {
+	tests := []struct {
+		name    string
+		exec    bool
+		query   string
+		opts    []SQLOption
+		fromCtx bool
+		wantErr error
+		want    string
+	}{
+		{
+			name:  "exec",
+			exec:  true,
+			query: "UPDATE users SET name = $1 WHERE id = $2",
+			want: `{
+				"level":"INFO",
+				"time":"not",
+				"msg":"sql exec",
+				"sql":{
+					"query":"UPDATE users SET name = $1 WHERE id = $2",
+					"args":2,
+					"rows_affected":3
+				},
+				"duration":1000000000
+			}`,
+		},
+		{
+			name:  "query",
+			query: "SELECT n FROM users WHERE name = $1 AND id = $2",
+			want: `{
+				"level":"INFO",
+				"time":"not",
+				"msg":"sql query",
+				"sql":{
+					"query":"SELECT n FROM users WHERE name = $1 AND id = $2",
+					"args":2
+				},
+				"duration":1000000000
+			}`,
+		},
+		{
+			name:    "error",
+			exec:    true,
+			query:   "fail",
+			wantErr: errFakeQuery,
+			want: `{
+				"level":"ERROR",
+				"time":"not",
+				"msg":"sql exec",
+				"sql":{
+					"query":"fail",
+					"args":2
+				},
+				"duration":1000000000,
+				"error":"fake query failed"
+			}`,
+		},
+		{
+			name:  "slow with values",
+			query: "SELECT 1",
+			opts: []SQLOption{
+				WithSQLSlowThreshold(time.Millisecond),
+				WithSQLArgValues(),
+			},
+			want: `{
+				"level":"WARN",
+				"time":"not",
+				"msg":"sql query",
+				"sql":{
+					"query":"SELECT 1",
+					"args":2,
+					"values":["name",1]
+				},
+				"duration":1000000000,
+				"slow":true
+			}`,
+		},
+		{
+			name:    "logger from ctx",
+			query:   "SELECT 1",
+			fromCtx: true,
+			want: `{
+				"level":"INFO",
+				"time":"not",
+				"msg":"sql query",
+				"ctx":{
+					"sql":{
+						"query":"SELECT 1",
+						"args":2
+					},
+					"duration":1000000000
+				}
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		for _, withContext := range []bool{false, true} {
+			name := tt.name + "/prepared"
+			if withContext {
+				name = tt.name + "/context"
+			}
+			t.Run(name, func(t *testing.T) {
+				out, logger := newTestLogger()
+				opts := append([]SQLOption{
+					WithSQLFallbackLogger(logger),
+					WithSQLDurationFunc(func(time.Time) time.Duration {
+						return time.Second
+					}),
+				}, tt.opts...)
+				db := sql.OpenDB(WrapConnector(fakeDriver{withContext: withContext}, opts...))
+				defer db.Close()
+
+				ctx := context.Background()
+				if tt.fromCtx {
+					ctx = ToContext(ctx, logger.WithGroup("ctx"))
+				}
+				var err error
+				if tt.exec {
+					_, err = db.ExecContext(ctx, tt.query, "name", 1)
+				} else {
+					var rows *sql.Rows
+					rows, err = db.QueryContext(ctx, tt.query, "name", 1)
+					if err == nil {
+						require.NoError(t, rows.Close())
+					}
+				}
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.JSONEq(t, tt.want, out.String())
+			})
+		}
+	}
+}
+
+// fakeDriverOut receives the logs of the registered "logging-fake" driver.
+var (
+	fakeDriverOnce sync.Once
+	fakeDriverOut  *strings.Builder
+)
+
+func TestWrapDriver(t *testing.T) {
+	// a driver can only be registered once per process.
+	fakeDriverOnce.Do(func() {
+		var logger *slog.Logger
+		fakeDriverOut, logger = newTestLogger()
+		sql.Register("logging-fake", WrapDriver(fakeOnlyDriver{}, WithSQLFallbackLogger(logger)))
+	})
+	out := fakeDriverOut
+	out.Reset()
+	db, err := sql.Open("logging-fake", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("DELETE FROM users")
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `"rows_affected":3`)
+}
+
+func TestWrapConnector_noLogger(t *testing.T) {
+	db := sql.OpenDB(WrapConnector(fakeDriver{withContext: true}))
+	defer db.Close()
+	_, err := db.Exec("DELETE FROM users")
+	require.NoError(t, err)
+}
+
+func TestWrapConnector_beginTx(t *testing.T) {
+	db := sql.OpenDB(WrapConnector(fakeDriver{}))
+	defer db.Close()
+	_, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+	assert.EqualError(t, err, "sql: driver does not support non-default isolation level")
+	_, err = db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	assert.EqualError(t, err, "sql: driver does not support read-only transactions")
+}
+
+// fakeConverterStmt implements [driver.ColumnConverter].
+type fakeConverterStmt struct {
+	fakeStmt
+}
+
+func (fakeConverterStmt) ColumnConverter(int) driver.ValueConverter {
+	return driver.DefaultParameterConverter
+}
+
+func Test_wrapStmt(t *testing.T) {
+	stmt := wrapStmt(&loggedStmt{Stmt: fakeStmt{}})
+	assert.NotImplements(t, (*driver.NamedValueChecker)(nil), stmt)
+	assert.NotImplements(t, (*driver.ColumnConverter)(nil), stmt)
+
+	stmt = wrapStmt(&loggedStmt{Stmt: fakeConverterStmt{}})
+	assert.NotImplements(t, (*driver.NamedValueChecker)(nil), stmt)
+	assert.Implements(t, (*driver.ColumnConverter)(nil), stmt)
+}
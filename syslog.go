@@ -0,0 +1,362 @@
+package logging
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// SyslogConfig configures the [SyslogHandler].
+type SyslogConfig struct {
+	// Network is one of "udp", "tcp", "tls" or "unix".
+	// Defaults to "unix", the local syslog daemon.
+	Network string `json:"network"`
+	// Address of the syslog server, like "logs.example.com:514".
+	// For "unix", it is the socket path and defaults to the first
+	// of /dev/log, /var/run/syslog and /var/run/log which exists.
+	Address string `json:"address"`
+	// Format is either "rfc5424" (default) or "rfc3164".
+	Format string `json:"format"`
+	// Facility is the name of the syslog facility,
+	// like "daemon" or "local0". Defaults to "user".
+	Facility string `json:"facility"`
+	// Hostname defaults to [os.Hostname].
+	Hostname string `json:"hostname"`
+	// AppName defaults to the name of the executable.
+	AppName string `json:"appName" yaml:"appName"`
+	// StructuredDataID is the SD-ID of the RFC 5424 STRUCTURED-DATA
+	// element holding the attributes, defaults to "attrs@32473".
+	StructuredDataID string `json:"structuredDataID" yaml:"structuredDataID"`
+	// Timeout for connecting and writing, defaults to 10 seconds.
+	// JSON expects nanoseconds, YAML accepts durations like "10s".
+	Timeout time.Duration `json:"timeout"`
+	// TLS is used for the "tls" network.
+	TLS *tls.Config `json:"-" yaml:"-"`
+
+	BatchOptions `yaml:",inline"`
+}
+
+const (
+	SyslogRFC5424 = "rfc5424"
+	SyslogRFC3164 = "rfc3164"
+)
+
+// SyslogHandler is a [Sink] sending records to a syslog server.
+//
+// Messages are sent one per datagram over "udp" and unix datagram sockets
+// and with octet-counted framing (RFC 6587) over "tcp", "tls"
+// and unix stream sockets.
+// Levels map to the syslog severities debug, info, notice, warning,
+// err and crit, where the logrus levels fatal and panic are crit.
+//
+// With RFC 5424, attributes are put into a STRUCTURED-DATA element,
+// with groups joined by a dot. With RFC 3164, they are appended
+// to the message in logfmt.
+// A broken connection is re-established on the next write.
+type SyslogHandler struct {
+	baseHandler
+	exporter *syslogExporter
+}
+
+// NewSyslogHandler creates a [SyslogHandler] and starts
+// the background goroutine sending messages.
+// The connection is established with the first message.
+// It returns an error for an unknown network, format or facility.
+// If opts is nil, the default options are used.
+func NewSyslogHandler(config SyslogConfig, opts *slog.HandlerOptions) (*SyslogHandler, error) {
+	switch config.Network {
+	case "":
+		config.Network = "unix"
+	case "udp", "tcp", "tls", "unix":
+	default:
+		return nil, fmt.Errorf("logging: syslog network %q not supported", config.Network)
+	}
+	switch config.Format {
+	case "":
+		config.Format = SyslogRFC5424
+	case SyslogRFC5424, SyslogRFC3164:
+	default:
+		return nil, fmt.Errorf("logging: syslog format %q not supported", config.Format)
+	}
+	facility, ok := syslogFacilities[config.Facility]
+	if !ok {
+		return nil, fmt.Errorf("logging: syslog facility %q not supported", config.Facility)
+	}
+	if config.Hostname == "" {
+		config.Hostname, _ = os.Hostname()
+	}
+	if config.AppName == "" {
+		config.AppName = filepath.Base(os.Args[0])
+	}
+	if config.StructuredDataID == "" {
+		config.StructuredDataID = "attrs@32473"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	e := &syslogExporter{
+		config:   config,
+		facility: facility,
+		pid:      os.Getpid(),
+		conn:     newSinkConn(config.Network, config.Address, config.TLS, config.Timeout),
+	}
+	if config.Network == "unix" {
+		dialer := &net.Dialer{Timeout: config.Timeout}
+		e.conn.dial = func(ctx context.Context) (net.Conn, error) {
+			return dialUnixSyslog(ctx, dialer, config.Address)
+		}
+	}
+	e.conn.frame = frameSyslog
+	e.batcher = newBatcher(config.BatchOptions, e.send, spoolBytes)
+	return &SyslogHandler{
+		baseHandler: newBaseHandler(opts),
+		exporter:    e,
+	}, nil
+}
+
+func (h *SyslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &SyslogHandler{baseHandler: h.withAttrs(attrs), exporter: h.exporter}
+}
+
+func (h *SyslogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SyslogHandler{baseHandler: h.withGroup(name), exporter: h.exporter}
+}
+
+func (h *SyslogHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := h.recordAttrs(r)
+	if h.opts.AddSource && r.PC != 0 {
+		attrs = append(attrs, groupedAttr{attr: slog.String(slog.SourceKey, recordSource(r))})
+	}
+	var msg []byte
+	if h.exporter.config.Format == SyslogRFC3164 {
+		msg = h.exporter.appendRFC3164(nil, r, attrs)
+	} else {
+		msg = h.exporter.appendRFC5424(nil, r, attrs)
+	}
+	return h.exporter.batcher.add(msg)
+}
+
+// Flush sends all buffered messages.
+func (h *SyslogHandler) Flush(ctx context.Context) error {
+	return h.exporter.batcher.Flush(ctx)
+}
+
+// Close sends all buffered messages and closes the connection.
+func (h *SyslogHandler) Close(ctx context.Context) error {
+	return errors.Join(
+		h.exporter.batcher.Close(ctx),
+		h.exporter.conn.close(),
+	)
+}
+
+type syslogExporter struct {
+	config   SyslogConfig
+	facility int
+	pid      int
+	batcher  *batcher[[]byte]
+	conn     *sinkConn
+}
+
+func (e *syslogExporter) send(ctx context.Context, msgs [][]byte) error {
+	for i, msg := range msgs {
+		if err := e.conn.write(ctx, msg); err != nil {
+			return &undeliveredError[[]byte]{
+				items: msgs[i:],
+				err:   e.batcher.sendError("syslog", len(msgs)-i, "messages", err),
+			}
+		}
+	}
+	return nil
+}
+
+// frameSyslog adds the octet-counting prefix
+// for stream connections.
+func frameSyslog(conn net.Conn, msg []byte) []byte {
+	if network := conn.RemoteAddr().Network(); network != "tcp" && network != "unix" {
+		return msg
+	}
+	b := make([]byte, 0, len(msg)+8)
+	b = strconv.AppendInt(b, int64(len(msg)), 10)
+	b = append(b, ' ')
+	return append(b, msg...)
+}
+
+// dialUnixSyslog connects to the local syslog daemon,
+// preferring datagram sockets like the standard log/syslog package.
+func dialUnixSyslog(ctx context.Context, dialer *net.Dialer, address string) (net.Conn, error) {
+	paths := []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+	if address != "" {
+		paths = []string{address}
+	}
+	var errs []error
+	for _, path := range paths {
+		conn, err := dialer.DialContext(ctx, "unixgram", path)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		conn, err = dialer.DialContext(ctx, "unix", path)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// appendRFC5424 appends the message in the format
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID name="value"...] MSG
+func (e *syslogExporter) appendRFC5424(b []byte, r slog.Record, attrs []groupedAttr) []byte {
+	b = e.appendPriority(b, r.Level)
+	b = append(b, '1', ' ')
+	if r.Time.IsZero() {
+		b = append(b, '-')
+	} else {
+		b = r.Time.AppendFormat(b, "2006-01-02T15:04:05.000000Z07:00")
+	}
+	b = append(b, ' ')
+	b = appendSyslogHeaderField(b, e.config.Hostname, 255)
+	b = append(b, ' ')
+	b = appendSyslogHeaderField(b, e.config.AppName, 48)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(e.pid), 10)
+	b = append(b, " - "...)
+	if len(attrs) == 0 {
+		b = append(b, '-')
+	} else {
+		b = append(b, '[')
+		b = appendSyslogName(b, e.config.StructuredDataID)
+		for _, a := range attrs {
+			b = append(b, ' ')
+			b = appendSyslogName(b, a.key("."))
+			b = append(b, '=', '"')
+			b = appendSyslogParamValue(b, syslogValue(a.attr.Value))
+			b = append(b, '"')
+		}
+		b = append(b, ']')
+	}
+	b = append(b, ' ')
+	return append(b, r.Message...)
+}
+
+// appendRFC3164 appends the message in the format
+// <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG key=value...
+func (e *syslogExporter) appendRFC3164(b []byte, r slog.Record, attrs []groupedAttr) []byte {
+	b = e.appendPriority(b, r.Level)
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	b = t.AppendFormat(b, time.Stamp)
+	b = append(b, ' ')
+	b = appendSyslogHeaderField(b, e.config.Hostname, 255)
+	b = append(b, ' ')
+	b = appendSyslogHeaderField(b, e.config.AppName, 32)
+	b = append(b, '[')
+	b = strconv.AppendInt(b, int64(e.pid), 10)
+	b = append(b, "]: "...)
+	b = append(b, r.Message...)
+	for _, a := range attrs {
+		b = appendLogfmtValue(b, a.key("."), a.attr.Value)
+	}
+	return b
+}
+
+func (e *syslogExporter) appendPriority(b []byte, level slog.Level) []byte {
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(e.facility*8+syslogSeverity(level)), 10)
+	return append(b, '>')
+}
+
+// syslogSeverity maps slog levels to syslog severities.
+// Levels above ERROR, like the logrus levels fatal
+// and panic, are critical.
+func syslogSeverity(level slog.Level) int {
+	switch {
+	case level > slog.LevelError:
+		return 2 // crit
+	case level == slog.LevelError:
+		return 3 // err
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level > slog.LevelInfo:
+		return 5 // notice
+	case level == slog.LevelInfo:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "": 1, "mail": 2, "daemon": 3,
+	"auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+func syslogValue(v slog.Value) string {
+	if v.Kind() == slog.KindAny {
+		return jsonString(v)
+	}
+	return v.String()
+}
+
+// appendSyslogHeaderField appends s with at most max printable
+// ASCII characters, or "-" if s is empty.
+func appendSyslogHeaderField(b []byte, s string, max int) []byte {
+	if s == "" {
+		return append(b, '-')
+	}
+	for i := 0; i < len(s) && i < max; i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// appendSyslogName appends an SD-ID or PARAM-NAME, which are
+// limited to 32 printable ASCII characters except '=', ' ', ']' and '"'.
+func appendSyslogName(b []byte, s string) []byte {
+	if s == "" {
+		return append(b, '_')
+	}
+	for i := 0; i < len(s) && i < 32; i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// appendSyslogParamValue escapes '"', '\' and ']' in a PARAM-VALUE.
+func appendSyslogParamValue(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			b = append(b, '\\', c)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
@@ -0,0 +1,217 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var syslogTestTime = time.Date(2024, 5, 6, 7, 8, 9, 123456000, time.UTC)
+
+func newTestSyslogHandler(t *testing.T, config SyslogConfig) *SyslogHandler {
+	t.Helper()
+	config.Hostname = "host"
+	config.AppName = "app"
+	config.FlushInterval = time.Hour
+	h, err := NewSyslogHandler(config, &slog.HandlerOptions{Level: slog.LevelDebug})
+	require.NoError(t, err)
+	h.exporter.pid = 42
+	return h
+}
+
+func handleSyslogTestRecords(t *testing.T, h *SyslogHandler) {
+	t.Helper()
+	logger := slog.New(h).With("id", 1)
+	for _, r := range []slog.Record{
+		slog.NewRecord(syslogTestTime, slog.LevelInfo, "hello", 0),
+		slog.NewRecord(syslogTestTime, slog.LevelError, "failed", 0),
+	} {
+		r.AddAttrs(slog.Group("req", slog.String("path", `/a"]\`)))
+		require.NoError(t, logger.Handler().Handle(context.Background(), r))
+	}
+	require.NoError(t, h.Close(context.Background()))
+}
+
+var syslogTestMessages = []string{
+	`<14>1 2024-05-06T07:08:09.123456Z host app 42 - [attrs@32473 id="1" req.path="/a\"\]\\"] hello`,
+	`<11>1 2024-05-06T07:08:09.123456Z host app 42 - [attrs@32473 id="1" req.path="/a\"\]\\"] failed`,
+}
+
+func TestSyslogHandler_udp(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	handleSyslogTestRecords(t, newTestSyslogHandler(t, SyslogConfig{
+		Network: "udp",
+		Address: conn.LocalAddr().String(),
+	}))
+	assert.Equal(t, syslogTestMessages, readDatagrams(t, conn, 2))
+}
+
+func TestSyslogHandler_unixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	conn, err := net.ListenPacket("unixgram", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	handleSyslogTestRecords(t, newTestSyslogHandler(t, SyslogConfig{
+		Address:  path,
+		Format:   SyslogRFC3164,
+		Facility: "local0",
+	}))
+	assert.Equal(t, []string{
+		`<134>May  6 07:08:09 host app[42]: hello id=1 req.path="/a\"]\\"`,
+		`<131>May  6 07:08:09 host app[42]: failed id=1 req.path="/a\"]\\"`,
+	}, readDatagrams(t, conn, 2))
+}
+
+func TestSyslogHandler_tcp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	messages := acceptOctetCounted(t, listener)
+
+	handleSyslogTestRecords(t, newTestSyslogHandler(t, SyslogConfig{
+		Network: "tcp",
+		Address: listener.Addr().String(),
+	}))
+	assert.Equal(t, syslogTestMessages, <-messages)
+}
+
+func TestSyslogHandler_tls(t *testing.T) {
+	ts := httptest.NewTLSServer(nil)
+	ts.Close()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: ts.TLS.Certificates})
+	require.NoError(t, err)
+	defer listener.Close()
+	messages := acceptOctetCounted(t, listener)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+	handleSyslogTestRecords(t, newTestSyslogHandler(t, SyslogConfig{
+		Network: "tls",
+		Address: listener.Addr().String(),
+		TLS:     &tls.Config{RootCAs: roots, ServerName: "example.com"},
+	}))
+	assert.Equal(t, syslogTestMessages, <-messages)
+}
+
+func TestSyslogHandler_reconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	h := newTestSyslogHandler(t, SyslogConfig{
+		Network: "tcp",
+		Address: listener.Addr().String(),
+	})
+	slog.New(h).Info("first")
+	require.NoError(t, h.Flush(context.Background()))
+	server, err := listener.Accept()
+	require.NoError(t, err)
+	defer server.Close()
+	// break the connection, the next write reconnects.
+	require.NoError(t, h.exporter.conn.conn.Close())
+
+	messages := acceptOctetCounted(t, listener)
+	slog.New(h).Info("second")
+	require.NoError(t, h.Close(context.Background()))
+	got := <-messages
+	require.Len(t, got, 1)
+	assert.True(t, strings.HasSuffix(got[0], " second"), got[0])
+}
+
+func TestNewSyslogHandler_errors(t *testing.T) {
+	for _, config := range []SyslogConfig{
+		{Network: "carrier-pigeon"},
+		{Format: "rfc1149"},
+		{Facility: "local8"},
+	} {
+		_, err := NewSyslogHandler(config, nil)
+		assert.Error(t, err)
+	}
+}
+
+func Test_syslogSeverity(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  int
+	}{
+		{slogLevel(7), 7},         // logrus trace
+		{slog.LevelDebug, 7},      //
+		{slog.LevelInfo, 6},       //
+		{slog.LevelInfo + 2, 5},   //
+		{slog.LevelWarn, 4},       //
+		{slog.LevelError, 3},      //
+		{slogLevel(1), 2},         // logrus fatal
+		{slogLevel(0), 2},         // logrus panic
+		{slog.LevelError + 1, 2},  //
+		{slog.LevelDebug - 10, 7}, //
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, syslogSeverity(tt.level), tt.level)
+	}
+}
+
+func readDatagrams(t *testing.T, conn net.PacketConn, n int) []string {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	msgs := make([]string, 0, n)
+	buf := make([]byte, 2048)
+	for range n {
+		read, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		msgs = append(msgs, string(buf[:read]))
+	}
+	return msgs
+}
+
+// acceptOctetCounted accepts a single connection and returns
+// the messages read until it is closed.
+func acceptOctetCounted(t *testing.T, listener net.Listener) chan []string {
+	t.Helper()
+	messages := make(chan []string, 1)
+	go func() {
+		var msgs []string
+		defer func() { messages <- msgs }()
+		conn, err := listener.Accept()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			length, err := r.ReadString(' ')
+			if err == io.EOF {
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+			if !assert.NoError(t, err) {
+				return
+			}
+			msg := make([]byte, n)
+			if _, err = io.ReadFull(r, msg); !assert.NoError(t, err) {
+				return
+			}
+			msgs = append(msgs, string(msg))
+		}
+	}()
+	return messages
+}